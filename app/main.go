@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"notification/config"
+	"notification/domain"
 	"notification/services/notification/delivery"
 	"notification/services/notification/repository"
 	"notification/services/notification/usecase"
@@ -51,7 +53,7 @@ func startHTTP() {
 		return
 	}
 
-	meow, eAuth, eAdress, schoolPhone, emailSender, err := config.InitSender()
+	meow, mailTransport, schoolPhone, emailSender, err := config.InitSender()
 	if err != nil {
 		fmt.Println(err)
 		log.Fatal("Failed to boot Sender Service")
@@ -75,8 +77,46 @@ func startHTTP() {
 	studentRepo := repository.NewStudentRepository(db)
 	studentUC := usecase.NewStudentUseCase(studentRepo, 100*time.Second)
 	// Sender
-	senderRepo := repository.NewSenderRepository(db, eAuth, *eAdress, *schoolPhone, *emailSender, meow)
+	notifiers := repository.NewNotifierRegistry(config.GetEnabledChannels(), log)
+	notifiers.Register(repository.NewEmailNotifier(mailTransport, *emailSender))
+	waPerMinute, waBurst, waJitter := config.GetWhatsAppRateLimit()
+	notifiers.Register(repository.NewRateLimitedNotifier(repository.NewWhatsAppNotifier(meow), waPerMinute, waBurst, waJitter, config.GetOutboxLease()/2))
+	if smsGateway := config.GetSMSGateway(); smsGateway != nil {
+		notifiers.Register(repository.NewSMSGatewayNotifier(*smsGateway))
+	}
+	// WhatsApp session, pairing is done through the API while the server runs
+	waBackoffBase, waBackoffMax := config.GetWhatsAppReconnectBackoff()
+	whatsappSession := repository.NewWhatsAppSession(meow, notifiers, config.GetAdminEmail(), waBackoffBase, waBackoffMax, log)
+	whatsappSessionUC := usecase.NewWhatsAppSessionUseCase(whatsappSession, 30*time.Second)
+	repository.ListenWhatsAppReceipts(meow, notifRepo, log)
+	senderRepo := repository.NewSenderRepository(db, notifiers, config.GetSchoolName(), *schoolPhone, config.GetAbsenceDedupWindow(), config.GetAdminEmail())
 	senderUC := usecase.NewSenderUseCase(senderRepo, 30*time.Second)
+	// Outbox
+	outboxRepo := repository.NewOutboxRepository(db, notifiers, config.GetOutboxLease(), config.GetOutboxRetryPolicy(), config.GetOutboxFallbackAfter(), log)
+	outboxUC := usecase.NewOutboxUseCase(outboxRepo, config.GetOutboxLease())
+
+	templateRepo := repository.NewMessageTemplateRepository(db)
+	templateUC := usecase.NewMessageTemplateUseCase(templateRepo, 30*time.Second)
+
+	attendanceRepo := repository.NewAttendanceRepository(db)
+	attendanceUC := usecase.NewAttendanceUseCase(attendanceRepo, senderRepo, 30*time.Second)
+
+	excusedAbsenceRepo := repository.NewExcusedAbsenceRepository(db, *schoolPhone)
+	excusedAbsenceUC := usecase.NewExcusedAbsenceUseCase(excusedAbsenceRepo, 30*time.Second)
+
+	schoolSettingRepo := repository.NewSchoolSettingRepository(db)
+	schoolSettingUC := usecase.NewSchoolSettingUseCase(schoolSettingRepo, 30*time.Second)
+
+	escalationRuleRepo := repository.NewEscalationRuleRepository(db)
+	escalationRuleUC := usecase.NewEscalationRuleUseCase(escalationRuleRepo, 30*time.Second)
+
+	parentReplyRepo := repository.NewParentReplyRepository(db)
+	parentReplyUC := usecase.NewParentReplyUseCase(parentReplyRepo, 30*time.Second)
+	repository.ListenWhatsAppReplies(meow, parentReplyRepo, excusedAbsenceRepo, log)
+
+	// The lease outlives the timeout so a running schedule is only taken over once its run gave up
+	scheduleRepo := repository.NewScheduledNotificationRepository(db, senderRepo, config.GetSchedulerLease())
+	scheduleUC := usecase.NewScheduledNotificationUseCase(scheduleRepo, config.GetSchedulerLease()/2)
 
 	// // Register delivery here
 	// delivery.NewNotificationHandler(app, notifUC)
@@ -93,6 +133,25 @@ func startHTTP() {
 	delivery.NewStudentParentHandlerDeploy(app, studentParentUC)
 	delivery.NewSenderDeliveryDeploy(app, senderUC)
 	delivery.NewStudentDeliveryDeploy(app, studentUC)
+	delivery.NewOutboxDeliveryDeploy(app, outboxUC)
+	delivery.NewMessageTemplateDeliveryDeploy(app, templateUC)
+	delivery.NewAttendanceDeliveryDeploy(app, attendanceUC)
+	delivery.NewExcusedAbsenceDeliveryDeploy(app, excusedAbsenceUC)
+	delivery.NewSchoolSettingDeliveryDeploy(app, schoolSettingUC)
+	delivery.NewEscalationRuleDeliveryDeploy(app, escalationRuleUC)
+	delivery.NewScheduledNotificationDeliveryDeploy(app, scheduleUC)
+	delivery.NewWhatsAppSessionDeliveryDeploy(app, whatsappSessionUC)
+	delivery.NewParentReplyDeliveryDeploy(app, parentReplyUC)
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+
+	if err := whatsappSession.Start(workerCtx); err != nil {
+		log.Errorf("WhatsApp is not connected yet: %v", err)
+	}
+
+	startOutboxWorkers(workerCtx, outboxUC, config.GetOutboxWorkers(), config.GetOutboxPollInterval())
+	startDigestRunner(workerCtx, senderUC, time.Minute)
+	startScheduler(workerCtx, scheduleUC, config.GetSchedulerPollInterval())
 
 	wg.Add(1)
 	go func() {
@@ -110,10 +169,99 @@ func startHTTP() {
 
 	log.Info("Shutting down the server...")
 
-	if err := app.Shutdown(); err != nil {
+	// Open event streams would otherwise keep the shutdown waiting
+	if err := app.ShutdownWithTimeout(10 * time.Second); err != nil {
 		log.Errorf("Error during server shutdown: %v", err)
 	}
 
+	stopWorkers()
+
 	wg.Wait()
+	meow.Disconnect()
+	mailTransport.Close()
 	log.Info("Server shut down gracefully")
 }
+
+// startOutboxWorkers drains the outbox in the background until ctx is cancelled,
+// idle workers sleep for pollInterval before checking for new messages again.
+func startOutboxWorkers(ctx context.Context, uc domain.OutboxUseCase, workers int, pollInterval time.Duration) {
+	log.Infof("Starting %d outbox workers", workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+
+			for {
+				processed, err := uc.DispatchNext(ctx)
+				if err != nil {
+					log.Errorf("Outbox worker %d: %v", workerID, err)
+				}
+
+				if processed {
+					continue
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(pollInterval):
+				}
+			}
+		}(i + 1)
+	}
+}
+
+// startDigestRunner checks every interval whether the daily absence digest is due,
+// the sender only queues it once per day so running it often is harmless.
+func startDigestRunner(ctx context.Context, uc domain.SenderUseCase, interval time.Duration) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				job, err := uc.SendDigest(ctx)
+				if err != nil {
+					log.Errorf("Absence digest: %v", err)
+					continue
+				}
+				if job != nil {
+					log.Infof("Absence digest queued as job %s for %d recipients", job.JobID, job.TotalRecipients)
+				}
+			}
+		}
+	}()
+}
+
+// startScheduler runs the due scheduled notifications one after another until ctx is cancelled,
+// it sleeps for pollInterval whenever nothing is due.
+func startScheduler(ctx context.Context, uc domain.ScheduledNotificationUseCase, pollInterval time.Duration) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			processed, err := uc.RunNext(ctx)
+			if err != nil {
+				log.Errorf("Scheduler: %v", err)
+			}
+
+			if processed {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+		}
+	}()
+}
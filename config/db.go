@@ -64,6 +64,10 @@ func autoMigrate(db *gorm.DB) error {
 		&domain.Student{},
 		&domain.User{},
 		&domain.Subject{},
+		&domain.MessageTemplate{},
+		&domain.SchoolSetting{},
+		&domain.EscalationRule{},
+		&domain.SchoolHoliday{},
 	); err != nil {
 		return fmt.Errorf("failed to migrate base tables: %w", err)
 	}
@@ -71,8 +75,15 @@ func autoMigrate(db *gorm.DB) error {
 	// Migrasi tabel yang memiliki foreign key
 	if err := db.AutoMigrate(
 		&domain.TestScore{},
+		&domain.Attendance{},
+		&domain.ExcusedAbsence{},
 		&domain.AttendanceNotificationHistory{},
 		&domain.ParentDataChangeRequest{},
+		&domain.OutboxMessage{},
+		&domain.NotificationJob{},
+		&domain.EscalationAlert{},
+		&domain.ScheduledNotification{},
+		&domain.ParentReply{},
 	); err != nil {
 		return fmt.Errorf("failed to migrate relational tables: %w", err)
 	}
@@ -107,5 +118,17 @@ func autoMigrate(db *gorm.DB) error {
 		fmt.Println("Admin account created")
 	}
 
+	if err := seedMessageTemplates(db); err != nil {
+		return err
+	}
+
+	if err := seedSchoolSetting(db); err != nil {
+		return err
+	}
+
+	if err := seedEscalationRules(db); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"notification/domain"
+
+	"gorm.io/gorm"
+)
+
+// seedEscalationRules inserts the stock escalation rules that are missing by name, edited rules are left untouched.
+func seedEscalationRules(db *gorm.DB) error {
+	rules := []domain.EscalationRule{
+		{Name: "3 unexcused absences in a week", Period: domain.EscalationPeriodWeek, Unit: domain.EscalationUnitCount, Threshold: 3},
+		{Name: "10% of sessions in a month", Period: domain.EscalationPeriodMonth, Unit: domain.EscalationUnitPercent, Threshold: 10, MinSessions: 10},
+	}
+
+	for _, rule := range rules {
+		var existing domain.EscalationRule
+		err := db.Where("name = ?", rule.Name).Attrs(rule).FirstOrCreate(&existing).Error
+		if err != nil {
+			return fmt.Errorf("failed to seed escalation rule %s: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"notification/domain"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetOutboxWorkers returns how many background workers drain the outbox (OUTBOX_WORKERS, default 5).
+func GetOutboxWorkers() int {
+	v, err := strconv.Atoi(os.Getenv("OUTBOX_WORKERS"))
+	if err != nil || v <= 0 {
+		return 5
+	}
+	return v
+}
+
+// GetOutboxPollInterval returns how long an idle worker waits before polling again (OUTBOX_POLL_INTERVAL, default 2s).
+func GetOutboxPollInterval() time.Duration {
+	return getDurationEnv("OUTBOX_POLL_INTERVAL", 2*time.Second)
+}
+
+// GetOutboxLease returns how long a claimed message stays locked to a worker (OUTBOX_LEASE, default 2m).
+func GetOutboxLease() time.Duration {
+	return getDurationEnv("OUTBOX_LEASE", 2*time.Minute)
+}
+
+// GetOutboxRetryPolicy returns how failed sends are retried: OUTBOX_MAX_ATTEMPTS (default 5)
+// attempts, waiting OUTBOX_BACKOFF_BASE (default 30s) doubled per attempt up to OUTBOX_BACKOFF_MAX (default 30m).
+func GetOutboxRetryPolicy() domain.RetryPolicy {
+	maxAttempts, err := strconv.Atoi(os.Getenv("OUTBOX_MAX_ATTEMPTS"))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+
+	return domain.RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BackoffBase: getDurationEnv("OUTBOX_BACKOFF_BASE", 30*time.Second),
+		BackoffMax:  getDurationEnv("OUTBOX_BACKOFF_MAX", 30*time.Minute),
+	}
+}
+
+// GetOutboxFallbackAfter returns how long a message may wait on a paused channel, e.g. WhatsApp while it is
+// logged out, before it is also queued on its fallback channel (OUTBOX_FALLBACK_AFTER, default 10m).
+func GetOutboxFallbackAfter() time.Duration {
+	return getDurationEnv("OUTBOX_FALLBACK_AFTER", 10*time.Minute)
+}
+
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return v
+}
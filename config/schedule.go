@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// GetSchedulerPollInterval returns how often the scheduler looks for due scheduled notifications (SCHEDULER_POLL_INTERVAL, default 30s).
+func GetSchedulerPollInterval() time.Duration {
+	return getDurationEnv("SCHEDULER_POLL_INTERVAL", 30*time.Second)
+}
+
+// GetSchedulerLease returns how long a running schedule stays locked before it is run again (SCHEDULER_LEASE, default 5m).
+func GetSchedulerLease() time.Duration {
+	return getDurationEnv("SCHEDULER_LEASE", 5*time.Minute)
+}
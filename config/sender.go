@@ -2,82 +2,90 @@ package config
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
-	"net/smtp"
+	"notification/domain"
+	"notification/mailer"
 	"os"
-	"path/filepath"
-	"sync"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
-	"github.com/skip2/go-qrcode"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	waLog "go.mau.fi/whatsmeow/util/log"
 )
 
-var (
-	meowWhatsapp *whatsmeow.Client
-	qrCodeSent   bool
-	mu           sync.Mutex
-)
-
-func InitSender() (*whatsmeow.Client, smtp.Auth, *string, *string, *string, error) {
+func InitSender() (*whatsmeow.Client, *mailer.Transport, *string, *string, error) {
 	// SMTP Emailer
 	emailSender, err := getSender()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	emailPassword, err := getPassword()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	smtpHost, err := getHost()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	smtpPort, err := getSMTPPort()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	schoolPhone, err := getSchoolPhone()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
-	smtpAuth := smtp.PlainAuth("", *emailSender, *emailPassword, *smtpHost)
+	smtpTLS, err := getSMTPTLS(*smtpPort)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
 
-	smtpAddr := fmt.Sprintf("%s:%s", *smtpHost, *smtpPort)
+	// Connections are pooled and reused across messages, the transport picks the auth mechanism the server offers
+	mailTransport := mailer.NewTransport(mailer.Config{
+		Host:           *smtpHost,
+		Port:           *smtpPort,
+		Username:       *emailSender,
+		Password:       *emailPassword,
+		TLS:            smtpTLS,
+		DialTimeout:    getDurationEnv("SMTP_DIAL_TIMEOUT", 10*time.Second),
+		SendTimeout:    getDurationEnv("SMTP_SEND_TIMEOUT", 30*time.Second),
+		IdleTimeout:    getDurationEnv("SMTP_IDLE_TIMEOUT", 30*time.Second),
+		MaxConnections: getSMTPMaxConnections(),
+	})
 
 	//Meow
 	dbms, err := getDBMS()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	user, err := getDBUser()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	pass, err := getDBPassword()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	dbname, err := getDBName()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	dbPort, err := getDBPort()
 	if err != nil {
-		return nil, nil, nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
 	ctx := context.Background()
@@ -94,66 +102,110 @@ func InitSender() (*whatsmeow.Client, smtp.Auth, *string, *string, *string, erro
 		panic(err)
 	}
 
+	// The client is connected by the WhatsApp session once the server runs, so that
+	// a missing pairing no longer blocks the start on a QR code
 	clientLog := waLog.Stdout("Client", "ERROR", true)
-	mClient := whatsmeow.NewClient(deviceStore, clientLog)
-	meowWhatsapp = mClient
-
-	if meowWhatsapp.Store.ID == nil {
-		qrChan, _ := meowWhatsapp.GetQRChannel(ctx)
-		err = meowWhatsapp.Connect()
-		if err != nil {
-			panic(err)
-		}
+	meowWhatsapp := whatsmeow.NewClient(deviceStore, clientLog)
+
+	return meowWhatsapp, mailTransport, schoolPhone, emailSender, nil
+}
+
+// GetAbsenceDedupWindow returns how far back a previous notice about the same absence (student, subject,
+// date and session) makes a new one a duplicate (ABSENCE_DEDUP_WINDOW). Zero, the default, means ever.
+func GetAbsenceDedupWindow() time.Duration {
+	return getDurationEnv("ABSENCE_DEDUP_WINDOW", 0)
+}
+
+// GetSchoolName returns the name printed on generated documents such as report cards (SCHOOL_NAME),
+// the app name when unset.
+func GetSchoolName() string {
+	v := strings.TrimSpace(os.Getenv("SCHOOL_NAME"))
+	if v == "" {
+		return GetAppName()
+	}
+	return v
+}
+
+// GetAdminEmail returns the address escalation alerts are sent to (ADMIN_EMAIL), nil when unset.
+func GetAdminEmail() *string {
+	v := strings.TrimSpace(os.Getenv("ADMIN_EMAIL"))
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+// GetWhatsAppRateLimit returns how WhatsApp messages are paced across all senders: WHATSAPP_RATE_PER_MINUTE
+// (default 20, 0 turns pacing off) messages per minute in bursts of up to WHATSAPP_BURST (default 3),
+// each delayed by a random jitter of up to WHATSAPP_JITTER (default 2s).
+func GetWhatsAppRateLimit() (int, int, time.Duration) {
+	perMinute := 20
+	if v, err := strconv.Atoi(os.Getenv("WHATSAPP_RATE_PER_MINUTE")); err == nil && v >= 0 {
+		perMinute = v
+	}
+
+	burst, err := strconv.Atoi(os.Getenv("WHATSAPP_BURST"))
+	if err != nil || burst <= 0 {
+		burst = 3
+	}
+
+	return perMinute, burst, getDurationEnv("WHATSAPP_JITTER", 2*time.Second)
+}
+
+// GetWhatsAppReconnectBackoff returns the delay before the first reconnect attempt after the WhatsApp
+// connection drops (WHATSAPP_RECONNECT_BASE, default 2s), doubled per failed attempt up to WHATSAPP_RECONNECT_MAX (default 5m).
+func GetWhatsAppReconnectBackoff() (time.Duration, time.Duration) {
+	return getDurationEnv("WHATSAPP_RECONNECT_BASE", 2*time.Second), getDurationEnv("WHATSAPP_RECONNECT_MAX", 5*time.Minute)
+}
+
+// GetEnabledChannels returns the notifier channels enabled for this deployment,
+// read from NOTIFIER_CHANNELS as a comma separated list (default: email,whatsapp,sms).
+func GetEnabledChannels() []string {
+	v := os.Getenv("NOTIFIER_CHANNELS")
+	if v == "" {
+		return []string{"email", "whatsapp", "sms"}
+	}
 
-		// Process QR code
-		for evt := range qrChan {
-			if evt.Event == "code" {
-				mu.Lock()
-				if !qrCodeSent {
-					fmt.Println("")
-					fmt.Println("IMPORTANT no WhatsApp session was found !!")
-					fmt.Println("Need admin to scan the QR code for the server to run properly!")
-					// fmt.Println("==============   QR CODE   ==============")
-					fmt.Println("Loading...")
-
-					err := generateQRCode(evt.Code, "qrcode.png")
-					if err != nil {
-						panic(err)
-					}
-
-					qr, err := qrcode.New(evt.Code, qrcode.Medium)
-					if err != nil {
-						panic(err)
-					}
-
-					fmt.Println(qr.ToSmallString(false)) // false means no border
-
-					fmt.Println("Loading...")
-
-					err = SendQRtoEmail(smtpAddr, &smtpAuth, *emailSender, "qrcode.png")
-					if err != nil {
-						panic(err)
-					}
-					fmt.Printf("Image of QR Code is sent to %s, go ahead and scan them :)\n", *emailSender)
-					fmt.Println("")
-
-					qrCodeSent = true
-				}
-				mu.Unlock()
-			} else {
-				fmt.Println("Login event:", evt.Event)
-			}
+	var channels []string
+	for _, channel := range strings.Split(v, ",") {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if channel != "" {
+			channels = append(channels, channel)
 		}
-	} else {
-		err = meowWhatsapp.Connect()
-		if err != nil {
-			panic(err)
+	}
+	return channels
+}
+
+// GetSMSGateway returns the HTTP SMS gateway used when WhatsApp cannot reach a parent, nil when SMS_GATEWAY_URL
+// is unset. The URL may hold {to} and {message}, SMS_GATEWAY_BODY is json (default), form or none and
+// SMS_GATEWAY_PARAMS adds fixed fields to the body as a comma separated list of key=value pairs.
+func GetSMSGateway() *domain.SMSGateway {
+	gatewayURL := strings.TrimSpace(os.Getenv("SMS_GATEWAY_URL"))
+	if gatewayURL == "" {
+		return nil
+	}
+
+	params := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("SMS_GATEWAY_PARAMS"), ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(key) != "" {
+			params[strings.TrimSpace(key)] = strings.TrimSpace(value)
 		}
-		fmt.Println("WhatsMeow initialized")
 	}
 
-	return meowWhatsapp, smtpAuth, &smtpAddr, schoolPhone, emailSender, nil
+	return &domain.SMSGateway{
+		URL:          gatewayURL,
+		Method:       strings.ToUpper(strings.TrimSpace(os.Getenv("SMS_GATEWAY_METHOD"))),
+		AuthHeader:   strings.TrimSpace(os.Getenv("SMS_GATEWAY_AUTH_HEADER")),
+		AuthValue:    os.Getenv("SMS_GATEWAY_AUTH_VALUE"),
+		BodyFormat:   strings.ToLower(strings.TrimSpace(os.Getenv("SMS_GATEWAY_BODY"))),
+		ToField:      strings.TrimSpace(os.Getenv("SMS_GATEWAY_TO_FIELD")),
+		MessageField: strings.TrimSpace(os.Getenv("SMS_GATEWAY_MESSAGE_FIELD")),
+		Params:       params,
+		Timeout:      getDurationEnv("SMS_GATEWAY_TIMEOUT", 10*time.Second),
+	}
 }
+
 func getSender() (*string, error) {
 	sender := os.Getenv("EMAIL_SENDER")
 	if sender == "" {
@@ -194,6 +246,30 @@ func getSMTPPort() (*string, error) {
 	return &port, nil
 }
 
+// getSMTPTLS reads SMTP_TLS (tls, starttls or none), by default port 465 uses implicit TLS and any other port STARTTLS.
+func getSMTPTLS(port string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("SMTP_TLS")))
+	switch mode {
+	case "":
+		if port == "465" {
+			return mailer.TLSImplicit, nil
+		}
+		return mailer.TLSStartTLS, nil
+	case mailer.TLSImplicit, mailer.TLSStartTLS, mailer.TLSNone:
+		return mode, nil
+	}
+	return "", fmt.Errorf("smtp tls mode invalid, value : %s", mode)
+}
+
+// getSMTPMaxConnections reads SMTP_MAX_CONNECTIONS, how many emails are sent at the same time (default 3).
+func getSMTPMaxConnections() int {
+	v, err := strconv.Atoi(os.Getenv("SMTP_MAX_CONNECTIONS"))
+	if err != nil || v <= 0 {
+		return 3
+	}
+	return v
+}
+
 func getDBPort() (*string, error) {
 	port := os.Getenv("DB_PORT")
 	if port == "" {
@@ -233,59 +309,3 @@ func getDBName() (*string, error) {
 	}
 	return &v, nil
 }
-
-func generateQRCode(data, filePath string) error {
-	// Generate QR code and save as an image file
-	err := qrcode.WriteFile(data, qrcode.Medium, 256, filePath)
-	if err != nil {
-		return fmt.Errorf("failed to generate QR code: %v", err)
-	}
-	return nil
-}
-
-func SendQRtoEmail(smtpAddr string, smtpAuth *smtp.Auth, emailSender string, qrFilePath string) error {
-	// Subject and body of the email
-	subject := "Subject: SINOAN QR Code Login\n"
-	body := "Please find the attached QR code for login.\n\n"
-
-	// Open the QR code file
-	fileData, err := os.ReadFile(qrFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to read QR code file: %v", err)
-	}
-
-	// Get the file name and MIME boundary
-	fileName := filepath.Base(qrFilePath)
-	boundary := "my-boundary-12345"
-
-	// Create the email header with MIME boundary
-	msg := []byte("From: " + emailSender + "\n" +
-		"To: " + emailSender + "\n" +
-		subject +
-		"MIME-Version: 1.0\n" +
-		"Content-Type: multipart/mixed; boundary=" + boundary + "\n\n" +
-		"--" + boundary + "\n" +
-		"Content-Type: text/plain; charset=\"utf-8\"\n\n" +
-		body + "\n\n" +
-		"--" + boundary + "\n" +
-		"Content-Type: image/png\n" +
-		"Content-Disposition: attachment; filename=\"" + fileName + "\"\n" +
-		"Content-Transfer-Encoding: base64\n\n")
-
-	// Encode the file content to base64 and append it to the message
-	msg = append(msg, []byte(encodeBase64(fileData))...)
-	msg = append(msg, []byte("\n--"+boundary+"--")...)
-
-	// Send the email with the attachment
-	err = smtp.SendMail(smtpAddr, *smtpAuth, emailSender, []string{emailSender}, msg)
-	if err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
-	}
-
-	return nil
-}
-
-// Helper function to encode file content to base64
-func encodeBase64(data []byte) string {
-	return base64.StdEncoding.EncodeToString(data)
-}
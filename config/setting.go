@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"notification/domain"
+
+	"gorm.io/gorm"
+)
+
+// seedSchoolSetting creates the single settings row with immediate absence notices when it is missing.
+func seedSchoolSetting(db *gorm.DB) error {
+	setting := domain.SchoolSetting{
+		SchoolSettingID: domain.SchoolSettingID,
+		AbsenceMode:     domain.AbsenceModeImmediate,
+		DigestCutoff:    "15:00",
+		Timezone:        "Local",
+		SchoolDays:      "1,2,3,4,5",
+	}
+
+	err := db.Where("school_setting_id = ?", domain.SchoolSettingID).FirstOrCreate(&setting).Error
+	if err != nil {
+		return fmt.Errorf("failed to seed school setting: %w", err)
+	}
+	return nil
+}
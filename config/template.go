@@ -0,0 +1,332 @@
+package config
+
+import (
+	"fmt"
+	"notification/domain"
+
+	"gorm.io/gorm"
+)
+
+const absenceSubjectEng = `Notification of Absence for {{.Student.Name}} at {{.Time}} {{.Meridiem}} on {{.Date}}`
+
+const absenceBodyEng = `SINOAN Service 🔔
+
+Dear {{if eq .Parent.Gender "male"}}Mr.{{else}}Mrs.{{end}} {{.Parent.Name}},
+
+We would like to inform you that your child,
+
+NSN: {{.Student.StudentNSN}},
+Name: {{.Student.Name}},
+Class: {{.Student.Grade}} {{.Student.GradeLabel}}.
+
+was absent from the lesson "{{upper .Subject.Name}}" on {{.Date}} at {{.Time}} {{.Meridiem}}.
+
+We have not yet received any reason for the absence. We kindly ask you to provide confirmation or further information regarding your child's condition.
+
+If you have any questions or require further assistance, please feel free to contact us at {{.SchoolPhone}}.
+
+Thank you for your attention and cooperation.`
+
+const absenceSubjectInd = `Pemberitahuan Ketidakhadiran untuk {{.Student.Name}} pada {{.Time}} {{.Meridiem}} tanggal {{.Date}}`
+
+const absenceBodyInd = `{{$sapaan := "ibu"}}{{$salam := "Ibu"}}{{if eq .Parent.Gender "male"}}{{$sapaan = "bapak"}}{{$salam = "Bapak"}}{{end}}Layanan SINOAN 🔔
+
+Yth. {{$salam}} {{.Parent.Name}},
+
+Kami ingin memberitahukan bahwa anak {{$sapaan}},
+
+NSN: {{.Student.StudentNSN}},
+Nama: {{.Student.Name}},
+Kelas: {{.Student.Grade}} {{.Student.GradeLabel}}.
+
+tidak hadir pada pelajaran "{{upper .Subject.Name}}" tanggal {{.Date}} pukul {{.Time}} {{.Meridiem}}.
+
+Kami belum menerima alasan ketidakhadiran tersebut. Kami mohon {{$sapaan}} dapat memberikan konfirmasi atau informasi lebih lanjut mengenai kondisi anak {{$sapaan}}.
+
+Jika {{$sapaan}} memiliki pertanyaan atau membutuhkan bantuan lebih lanjut, jangan ragu untuk menghubungi kami di {{.SchoolPhone}}.
+
+Terima kasih atas perhatian dan kerjasamanya.`
+
+const examResultSubjectEng = `Notification of Assessment Results for {{.Student.Name}} at {{.Time}} {{.Meridiem}}, {{.Date}}`
+
+const examResultBodyEng = `SINOAN Service 🔔
+
+Dear {{if eq .Parent.Gender "male"}}Mr.{{else}}Mrs.{{end}} {{.Parent.Name}},
+We would like to inform you about the {{.ExamType}} results for the following student:
+NSN: {{.Student.StudentNSN}},
+Name: {{.Student.Name}},
+Class: {{.Student.Grade}} {{.Student.GradeLabel}}.
+Below are the details of the test results for several subjects:
+{{range .Scores}}- Code ({{.Subject.SubjectCode}}) | Subject: {{.Subject.Name}} | Score: {{if .Score}}{{score .Score}}{{else}}No Score Yet | 0{{end}}
+{{end}}
+If you have any questions or need further information, you can contact us at {{.SchoolPhone}}.
+
+Thank you for your attention and cooperation.
+
+Sincerely,
+SINOAN Team`
+
+const examResultSubjectInd = `Pemberitahuan Hasil Penilaian {{.Student.Name}} pada {{.Time}} {{.Meridiem}}, tanggal {{.Date}}`
+
+const examResultBodyInd = `{{$sapaan := "ibu"}}{{$salam := "Ibu"}}{{if eq .Parent.Gender "male"}}{{$sapaan = "bapak"}}{{$salam = "Bapak"}}{{end}}Layanan SINOAN 🔔
+
+Yth. {{$salam}} {{.Parent.Name}},
+Kami ingin memberitahukan tentang hasil {{.ExamType}} untuk siswa berikut:
+NSN: {{.Student.StudentNSN}},
+Nama: {{.Student.Name}},
+Kelas: {{.Student.Grade}} {{.Student.GradeLabel}}.
+Berikut adalah detail hasil ujian untuk beberapa mata pelajaran:
+{{range .Scores}}- Kode ({{.Subject.SubjectCode}}) | Mata Pelajaran: {{.Subject.Name}} | Nilai: {{if .Score}}{{score .Score}}{{else}}Belum Ada Nilai | 0{{end}}
+{{end}}
+Jika {{$sapaan}} memiliki pertanyaan atau membutuhkan informasi lebih lanjut, {{$sapaan}} dapat menghubungi kami di {{.SchoolPhone}}.
+
+Terima kasih atas perhatian dan kerjasamanya.
+
+Hormat kami,
+Tim SINOAN`
+
+const absenceDigestSubjectEng = `Daily Absence Summary for {{.Student.Name}} on {{.Date}}`
+
+const absenceDigestBodyEng = `SINOAN Service 🔔
+
+Dear {{if eq .Parent.Gender "male"}}Mr.{{else}}Mrs.{{end}} {{.Parent.Name}},
+
+We would like to inform you that your child,
+
+NSN: {{.Student.StudentNSN}},
+Name: {{.Student.Name}},
+Class: {{.Student.Grade}} {{.Student.GradeLabel}}.
+
+was absent from the following lessons on {{.Date}}:
+{{range .Subjects}}- {{upper .Name}}
+{{end}}
+We have not yet received any reason for the absence. We kindly ask you to provide confirmation or further information regarding your child's condition.
+
+If you have any questions or require further assistance, please feel free to contact us at {{.SchoolPhone}}.
+
+Thank you for your attention and cooperation.`
+
+const absenceDigestSubjectInd = `Ringkasan Ketidakhadiran Harian {{.Student.Name}} tanggal {{.Date}}`
+
+const absenceDigestBodyInd = `{{$sapaan := "ibu"}}{{$salam := "Ibu"}}{{if eq .Parent.Gender "male"}}{{$sapaan = "bapak"}}{{$salam = "Bapak"}}{{end}}Layanan SINOAN 🔔
+
+Yth. {{$salam}} {{.Parent.Name}},
+
+Kami ingin memberitahukan bahwa anak {{$sapaan}},
+
+NSN: {{.Student.StudentNSN}},
+Nama: {{.Student.Name}},
+Kelas: {{.Student.Grade}} {{.Student.GradeLabel}}.
+
+tidak hadir pada pelajaran berikut tanggal {{.Date}}:
+{{range .Subjects}}- {{upper .Name}}
+{{end}}
+Kami belum menerima alasan ketidakhadiran tersebut. Kami mohon {{$sapaan}} dapat memberikan konfirmasi atau informasi lebih lanjut mengenai kondisi anak {{$sapaan}}.
+
+Jika {{$sapaan}} memiliki pertanyaan atau membutuhkan bantuan lebih lanjut, jangan ragu untuk menghubungi kami di {{.SchoolPhone}}.
+
+Terima kasih atas perhatian dan kerjasamanya.`
+
+const absenceEscalationSubjectEng = `Urgent: Repeated Absences of {{.Student.Name}}`
+
+const absenceEscalationBodyEng = `SINOAN Service ⚠️
+
+Dear {{if eq .Parent.Gender "male"}}Mr.{{else}}Mrs.{{end}} {{.Parent.Name}},
+
+Your child,
+
+NSN: {{.Student.StudentNSN}},
+Name: {{.Student.Name}},
+Class: {{.Student.Grade}} {{.Student.GradeLabel}}.
+
+has been absent without an excuse {{.Absences}} times out of {{.Sessions}} recorded lessons {{if eq .Period "week"}}this week{{else}}this month{{end}}.
+
+Repeated absences affect your child's learning and may have academic consequences. We urgently ask you to contact the school at {{.SchoolPhone}} to discuss your child's attendance.
+
+Thank you for your immediate attention.`
+
+const absenceEscalationSubjectInd = `Penting: Ketidakhadiran Berulang {{.Student.Name}}`
+
+const absenceEscalationBodyInd = `{{$sapaan := "ibu"}}{{$salam := "Ibu"}}{{if eq .Parent.Gender "male"}}{{$sapaan = "bapak"}}{{$salam = "Bapak"}}{{end}}Layanan SINOAN ⚠️
+
+Yth. {{$salam}} {{.Parent.Name}},
+
+Anak {{$sapaan}},
+
+NSN: {{.Student.StudentNSN}},
+Nama: {{.Student.Name}},
+Kelas: {{.Student.Grade}} {{.Student.GradeLabel}}.
+
+telah tidak hadir tanpa keterangan sebanyak {{.Absences}} kali dari {{.Sessions}} pelajaran yang tercatat {{if eq .Period "week"}}minggu ini{{else}}bulan ini{{end}}.
+
+Ketidakhadiran yang berulang mengganggu proses belajar anak dan dapat berdampak pada penilaian akademik. Kami mohon {{$sapaan}} segera menghubungi sekolah di {{.SchoolPhone}} untuk membicarakan kehadiran anak {{$sapaan}}.
+
+Terima kasih atas perhatian {{$sapaan}}.`
+
+const escalationAlertSubjectEng = `Escalation: {{.Student.Name}} ({{.Student.Grade}} {{.Student.GradeLabel}}) reached an absence threshold`
+
+const escalationAlertBodyEng = `SINOAN Service ⚠️
+
+The following student reached an absence escalation threshold:
+
+NSN: {{.Student.StudentNSN}},
+Name: {{.Student.Name}},
+Class: {{.Student.Grade}} {{.Student.GradeLabel}},
+Parent: {{.Parent.Name}} ({{.Parent.Telephone}}).
+
+Unexcused absences {{if eq .Period "week"}}this week{{else}}this month{{end}}: {{.Absences}} of {{.Sessions}} recorded lessons.
+
+The parent has been sent an escalation notice. Please follow up with the homeroom teacher.`
+
+const escalationAlertSubjectInd = `Eskalasi: {{.Student.Name}} ({{.Student.Grade}} {{.Student.GradeLabel}}) mencapai batas ketidakhadiran`
+
+const escalationAlertBodyInd = `Layanan SINOAN ⚠️
+
+Siswa berikut telah mencapai batas eskalasi ketidakhadiran:
+
+NSN: {{.Student.StudentNSN}},
+Nama: {{.Student.Name}},
+Kelas: {{.Student.Grade}} {{.Student.GradeLabel}},
+Orang tua: {{.Parent.Name}} ({{.Parent.Telephone}}).
+
+Ketidakhadiran tanpa keterangan {{if eq .Period "week"}}minggu ini{{else}}bulan ini{{end}}: {{.Absences}} dari {{.Sessions}} pelajaran yang tercatat.
+
+Orang tua telah dikirimi pemberitahuan eskalasi. Mohon ditindaklanjuti bersama wali kelas.`
+
+const excuseReceivedBodyEng = `SINOAN Service 📝
+
+Dear {{if eq .Parent.Gender "male"}}Mr.{{else}}Mrs.{{end}} {{.Parent.Name}},
+
+Thank you, we have received your excuse for {{.Student.Name}} ({{.Student.Grade}} {{.Student.GradeLabel}}):
+
+Reason: {{.Reason}},
+Date: {{.StartDate}}{{if gt .Days 1}} to {{.EndDate}} ({{.Days}} days){{end}}.
+
+Our staff will confirm it shortly. If you have any questions, please contact us at {{.SchoolPhone}}.`
+
+const excuseReceivedBodyInd = `{{$salam := "Ibu"}}{{if eq .Parent.Gender "male"}}{{$salam = "Bapak"}}{{end}}Layanan SINOAN 📝
+
+Yth. {{$salam}} {{.Parent.Name}},
+
+Terima kasih, keterangan ketidakhadiran untuk {{.Student.Name}} ({{.Student.Grade}} {{.Student.GradeLabel}}) telah kami terima:
+
+Alasan: {{.Reason}},
+Tanggal: {{.StartDate}}{{if gt .Days 1}} sampai {{.EndDate}} ({{.Days}} hari){{end}}.
+
+Staf kami akan segera mengonfirmasinya. Jika ada pertanyaan, silakan hubungi kami di {{.SchoolPhone}}.`
+
+const excuseApprovedBodyEng = `SINOAN Service ✅
+
+Dear {{if eq .Parent.Gender "male"}}Mr.{{else}}Mrs.{{end}} {{.Parent.Name}},
+
+The excuse for {{.Student.Name}} ({{.Reason}}) on {{.StartDate}}{{if gt .Days 1}} to {{.EndDate}}{{end}} has been approved.{{if .Note}}
+
+Note from the school: {{.Note}}{{end}}
+
+Thank you for letting us know.`
+
+const excuseApprovedBodyInd = `{{$salam := "Ibu"}}{{if eq .Parent.Gender "male"}}{{$salam = "Bapak"}}{{end}}Layanan SINOAN ✅
+
+Yth. {{$salam}} {{.Parent.Name}},
+
+Keterangan ketidakhadiran {{.Student.Name}} ({{.Reason}}) tanggal {{.StartDate}}{{if gt .Days 1}} sampai {{.EndDate}}{{end}} telah disetujui.{{if .Note}}
+
+Catatan dari sekolah: {{.Note}}{{end}}
+
+Terima kasih atas pemberitahuannya.`
+
+const excuseRejectedBodyEng = `SINOAN Service ❌
+
+Dear {{if eq .Parent.Gender "male"}}Mr.{{else}}Mrs.{{end}} {{.Parent.Name}},
+
+We are unable to accept the excuse for {{.Student.Name}} ({{.Reason}}) on {{.StartDate}}{{if gt .Days 1}} to {{.EndDate}}{{end}}.{{if .Note}}
+
+Note from the school: {{.Note}}{{end}}
+
+Please contact us at {{.SchoolPhone}} to discuss it further.`
+
+const excuseRejectedBodyInd = `{{$salam := "Ibu"}}{{if eq .Parent.Gender "male"}}{{$salam = "Bapak"}}{{end}}Layanan SINOAN ❌
+
+Yth. {{$salam}} {{.Parent.Name}},
+
+Mohon maaf, keterangan ketidakhadiran {{.Student.Name}} ({{.Reason}}) tanggal {{.StartDate}}{{if gt .Days 1}} sampai {{.EndDate}}{{end}} tidak dapat kami terima.{{if .Note}}
+
+Catatan dari sekolah: {{.Note}}{{end}}
+
+Silakan hubungi kami di {{.SchoolPhone}} untuk informasi lebih lanjut.`
+
+const excuseTooLongBodyEng = `SINOAN Service ❌
+
+Dear {{if eq .Parent.Gender "male"}}Mr.{{else}}Mrs.{{end}} {{.Parent.Name}},
+
+We are sorry, a leave of {{.Days}} days for {{.Student.Name}} cannot be requested by message.
+
+Please contact us at {{.SchoolPhone}} to arrange a longer leave.`
+
+const excuseTooLongBodyInd = `{{$salam := "Ibu"}}{{if eq .Parent.Gender "male"}}{{$salam = "Bapak"}}{{end}}Layanan SINOAN ❌
+
+Yth. {{$salam}} {{.Parent.Name}},
+
+Mohon maaf, izin selama {{.Days}} hari untuk {{.Student.Name}} tidak dapat diajukan melalui pesan.
+
+Silakan hubungi kami di {{.SchoolPhone}} untuk mengajukan izin yang lebih panjang.`
+
+// defaultMessageTemplates are the stock wordings, seeded once so staff can reword them later.
+func defaultMessageTemplates() []domain.MessageTemplate {
+	type wording struct {
+		eventType, language, subject, body string
+		channels                           []string
+	}
+
+	// Admin alerts only go by email, replies to a parent's WhatsApp message only by WhatsApp
+	both := []string{domain.ChannelEmail, domain.ChannelWhatsApp}
+	emailOnly := []string{domain.ChannelEmail}
+	whatsappOnly := []string{domain.ChannelWhatsApp}
+
+	wordings := []wording{
+		{domain.EventAbsence, domain.LanguageEnglish, absenceSubjectEng, absenceBodyEng, both},
+		{domain.EventAbsence, domain.LanguageIndonesian, absenceSubjectInd, absenceBodyInd, both},
+		{domain.EventAbsenceDigest, domain.LanguageEnglish, absenceDigestSubjectEng, absenceDigestBodyEng, both},
+		{domain.EventAbsenceDigest, domain.LanguageIndonesian, absenceDigestSubjectInd, absenceDigestBodyInd, both},
+		{domain.EventAbsenceEscalation, domain.LanguageEnglish, absenceEscalationSubjectEng, absenceEscalationBodyEng, both},
+		{domain.EventAbsenceEscalation, domain.LanguageIndonesian, absenceEscalationSubjectInd, absenceEscalationBodyInd, both},
+		{domain.EventEscalationAlert, domain.LanguageEnglish, escalationAlertSubjectEng, escalationAlertBodyEng, emailOnly},
+		{domain.EventEscalationAlert, domain.LanguageIndonesian, escalationAlertSubjectInd, escalationAlertBodyInd, emailOnly},
+		{domain.EventExamResult, domain.LanguageEnglish, examResultSubjectEng, examResultBodyEng, both},
+		{domain.EventExamResult, domain.LanguageIndonesian, examResultSubjectInd, examResultBodyInd, both},
+		{domain.EventExcuseReceived, domain.LanguageEnglish, "", excuseReceivedBodyEng, whatsappOnly},
+		{domain.EventExcuseReceived, domain.LanguageIndonesian, "", excuseReceivedBodyInd, whatsappOnly},
+		{domain.EventExcuseApproved, domain.LanguageEnglish, "", excuseApprovedBodyEng, whatsappOnly},
+		{domain.EventExcuseApproved, domain.LanguageIndonesian, "", excuseApprovedBodyInd, whatsappOnly},
+		{domain.EventExcuseRejected, domain.LanguageEnglish, "", excuseRejectedBodyEng, whatsappOnly},
+		{domain.EventExcuseRejected, domain.LanguageIndonesian, "", excuseRejectedBodyInd, whatsappOnly},
+		{domain.EventExcuseTooLong, domain.LanguageEnglish, "", excuseTooLongBodyEng, whatsappOnly},
+		{domain.EventExcuseTooLong, domain.LanguageIndonesian, "", excuseTooLongBodyInd, whatsappOnly},
+	}
+
+	var templates []domain.MessageTemplate
+	for _, w := range wordings {
+		for _, channel := range w.channels {
+			tmpl := domain.MessageTemplate{EventType: w.eventType, Language: w.language, Channel: channel, Body: w.body}
+			if channel == domain.ChannelEmail {
+				tmpl.Subject = w.subject
+			}
+			templates = append(templates, tmpl)
+		}
+	}
+	return templates
+}
+
+// seedMessageTemplates inserts the default templates that are missing, existing rows are left untouched.
+func seedMessageTemplates(db *gorm.DB) error {
+	for _, tmpl := range defaultMessageTemplates() {
+		var existing domain.MessageTemplate
+		err := db.Where("event_type = ? AND language = ? AND channel = ?", tmpl.EventType, tmpl.Language, tmpl.Channel).
+			Attrs(tmpl).
+			FirstOrCreate(&existing).Error
+		if err != nil {
+			return fmt.Errorf("failed to seed %s/%s/%s template: %w", tmpl.EventType, tmpl.Language, tmpl.Channel, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+	AttendanceSick    = "sick"
+	AttendanceExcused = "excused"
+	AttendanceLate    = "late"
+)
+
+// Attendance is one student's status in one session of a subject on a given day.
+type Attendance struct {
+	AttendanceID int        `gorm:"primaryKey;autoIncrement" json:"attendance_id"`
+	StudentNSN   string     `gorm:"type:varchar(10);not null;uniqueIndex:idx_attendance_session" json:"student_nsn"`
+	Student      Student    `gorm:"foreignKey:StudentNSN;references:StudentNSN;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"student"`
+	SubjectCode  string     `gorm:"type:varchar(5);not null;uniqueIndex:idx_attendance_session" json:"subject_code"`
+	Subject      Subject    `gorm:"foreignKey:SubjectCode;references:SubjectCode;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"subject"`
+	Date         time.Time  `gorm:"type:date;not null;uniqueIndex:idx_attendance_session" json:"date"`
+	Session      int        `gorm:"not null;default:0;uniqueIndex:idx_attendance_session" json:"session"`
+	Status       string     `gorm:"type:varchar(10);not null" json:"status"`
+	UserID       int        `gorm:"not null" json:"user_id"`
+	User         User       `gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+	NotifiedAt   *time.Time `json:"notified_at"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type RollCallEntry struct {
+	StudentNSN string `json:"student_nsn" valid:"required~Student NSN is required"`
+	Status     string `json:"status" valid:"required~Status is required,in(present|absent|sick|excused|late)~Status must be present, absent, sick, excused or late"`
+}
+
+// RollCall is a class register as submitted by a teacher, Date is formatted as 2006-01-02.
+type RollCall struct {
+	SubjectCode string          `json:"subject_code" valid:"required~Subject code is required"`
+	Date        string          `json:"date" valid:"required~Date is required"`
+	Session     int             `json:"session"`
+	Entries     []RollCallEntry `json:"entries" valid:"required~Entries are required"`
+}
+
+type RollCallResult struct {
+	Attendances []Attendance     `json:"attendances"`
+	Job         *NotificationJob `json:"job"`
+}
+
+type AttendanceRepo interface {
+	GetAttendances(ctx context.Context, date, subjectCode string) (*[]Attendance, error)
+}
+
+type AttendanceUseCase interface {
+	SubmitRollCall(ctx context.Context, rollCall *RollCall, userID int) (*RollCallResult, error)
+	GetAttendances(ctx context.Context, date, subjectCode string) (*[]Attendance, error)
+}
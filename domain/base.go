@@ -106,13 +106,18 @@ type UserResponse struct {
 }
 
 type AttendanceNotificationHistoryResponse struct {
-	Student        Student      `json:"student"`
-	Parent         Parent       `json:"parent"`
-	User           UserResponse `json:"user"`
-	Subject        Subject      `json:"subject"`
-	WhatsappStatus bool         `json:"whatsapp_status"`
-	EmailStatus    bool         `json:"email_status"`
-	CreatedAt      time.Time    `json:"created_at"`
+	Student             Student      `json:"student"`
+	Parent              Parent       `json:"parent"`
+	User                UserResponse `json:"user"`
+	Subject             Subject      `json:"subject"`
+	WhatsappStatus      bool         `json:"whatsapp_status"`
+	WhatsappDelivery    string       `json:"whatsapp_delivery"`
+	WhatsappSentAt      *time.Time   `json:"whatsapp_sent_at"`
+	WhatsappDeliveredAt *time.Time   `json:"whatsapp_delivered_at"`
+	WhatsappReadAt      *time.Time   `json:"whatsapp_read_at"`
+	EmailStatus         bool         `json:"email_status"`
+	SMSStatus           bool         `json:"sms_status"`
+	CreatedAt           time.Time    `json:"created_at"`
 }
 
 type StudentTestScore struct {
@@ -135,6 +140,7 @@ type IndividualExamScore struct {
 	StudentNSN            string                  `json:"student_nsn"`
 	Student               Student                 `json:"student"`
 	SubjectAndScoreResult []SubjectAndScoreResult `json:"subject_and_score_result"`
+	TestScoreIDs          []int                   `json:"-"`
 }
 
 type StudentsAssociateWithParent struct {
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	EscalationPeriodWeek  = "week"
+	EscalationPeriodMonth = "month"
+)
+
+const (
+	EscalationUnitCount   = "count"
+	EscalationUnitPercent = "percent"
+)
+
+// EscalationRule fires once per period for a student whose unexcused, notified absences reach the
+// threshold, counted as absences or as a percentage of the sessions recorded in the period.
+// MinSessions keeps percentage rules quiet early in the period when only a few sessions are recorded.
+type EscalationRule struct {
+	EscalationRuleID int       `gorm:"primaryKey;autoIncrement" json:"escalation_rule_id"`
+	Name             string    `gorm:"type:varchar(100);not null;unique" json:"name" valid:"required~Name is required"`
+	Period           string    `gorm:"type:varchar(10);not null" json:"period" valid:"required~Period is required,in(week|month)~Period must be week or month"`
+	Unit             string    `gorm:"type:varchar(10);not null" json:"unit" valid:"required~Unit is required,in(count|percent)~Unit must be count or percent"`
+	Threshold        int       `gorm:"not null" json:"threshold" valid:"required~Threshold is required"`
+	MinSessions      int       `gorm:"not null;default:0" json:"min_sessions"`
+	Active           *bool     `gorm:"not null;default:true" json:"active"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// EscalationAlert records a rule firing for a student, at most one per rule, student and period.
+type EscalationAlert struct {
+	EscalationAlertID int            `gorm:"primaryKey;autoIncrement" json:"escalation_alert_id"`
+	EscalationRuleID  int            `gorm:"not null;uniqueIndex:idx_escalation_alert_period" json:"escalation_rule_id"`
+	EscalationRule    EscalationRule `gorm:"foreignKey:EscalationRuleID;references:EscalationRuleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"escalation_rule"`
+	StudentNSN        string         `gorm:"type:varchar(10);not null;uniqueIndex:idx_escalation_alert_period" json:"student_nsn"`
+	Student           Student        `gorm:"foreignKey:StudentNSN;references:StudentNSN;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"student"`
+	PeriodStart       time.Time      `gorm:"type:date;not null;uniqueIndex:idx_escalation_alert_period" json:"period_start"`
+	Absences          int            `gorm:"not null" json:"absences"`
+	Sessions          int            `gorm:"not null" json:"sessions"`
+	JobID             *string        `gorm:"type:varchar(36);index" json:"job_id"`
+	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type EscalationRuleRepo interface {
+	GetAllEscalationRules(ctx context.Context) (*[]EscalationRule, error)
+	CreateEscalationRule(ctx context.Context, rule *EscalationRule) (*EscalationRule, error)
+	UpdateEscalationRule(ctx context.Context, escalationRuleID int, rule *EscalationRule) error
+	DeleteEscalationRule(ctx context.Context, escalationRuleID int) error
+	GetEscalationAlerts(ctx context.Context, studentNSN string) (*[]EscalationAlert, error)
+}
+
+type EscalationRuleUseCase interface {
+	GetAllEscalationRules(ctx context.Context) (*[]EscalationRule, error)
+	CreateEscalationRule(ctx context.Context, rule *EscalationRule) (*EscalationRule, error)
+	UpdateEscalationRule(ctx context.Context, escalationRuleID int, rule *EscalationRule) error
+	DeleteEscalationRule(ctx context.Context, escalationRuleID int) error
+	GetEscalationAlerts(ctx context.Context, studentNSN string) (*[]EscalationAlert, error)
+}
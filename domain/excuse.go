@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ExcuseStatusPending  = "pending"
+	ExcuseStatusApproved = "approved"
+	ExcuseStatusRejected = "rejected"
+
+	ExcuseSourceStaff  = "staff"
+	ExcuseSourceParent = "parent"
+)
+
+// ExcusedAbsence is a permission letter (izin/surat) covering a student from StartDate to EndDate inclusive,
+// absences inside the range are already known to the school so parents are not notified.
+// Excuses parents send through WhatsApp stay pending, and count only once staff approved them.
+type ExcusedAbsence struct {
+	ExcusedAbsenceID int        `gorm:"primaryKey;autoIncrement" json:"excused_absence_id"`
+	StudentNSN       string     `gorm:"type:varchar(10);not null;index" json:"student_nsn"`
+	Student          Student    `gorm:"foreignKey:StudentNSN;references:StudentNSN;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"student"`
+	StartDate        time.Time  `gorm:"type:date;not null" json:"start_date"`
+	EndDate          time.Time  `gorm:"type:date;not null" json:"end_date"`
+	Reason           string     `gorm:"type:varchar(255);not null" json:"reason"`
+	Status           string     `gorm:"type:varchar(10);not null;default:'approved';index" json:"status"`
+	Source           string     `gorm:"type:varchar(10);not null;default:'staff'" json:"source"`
+	ParentReplyID    *int       `gorm:"index" json:"parent_reply_id"`
+	UserID           *int       `json:"user_id"`
+	ReviewedBy       *int       `json:"reviewed_by"`
+	ReviewedAt       *time.Time `json:"reviewed_at"`
+	ReviewNote       *string    `gorm:"type:varchar(255)" json:"review_note"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ExcusedAbsencePayload carries dates formatted as 2006-01-02.
+type ExcusedAbsencePayload struct {
+	StudentNSN string `json:"student_nsn" valid:"required~Student NSN is required"`
+	StartDate  string `json:"start_date" valid:"required~Start date is required"`
+	EndDate    string `json:"end_date" valid:"required~End date is required"`
+	Reason     string `json:"reason" valid:"required~Reason is required"`
+}
+
+// ExcuseReviewPayload approves or rejects an excuse a parent submitted, the note is passed on to the parent.
+type ExcuseReviewPayload struct {
+	Status string  `json:"status" valid:"required~Status is required,in(approved|rejected)~Status must be approved or rejected"`
+	Note   *string `json:"note" valid:"length(0|255)~Note is too long,optional"`
+}
+
+type ExcusedAbsenceRepo interface {
+	GetAllExcusedAbsences(ctx context.Context, studentNSN, status string) (*[]ExcusedAbsence, error)
+	GetExcusedAbsenceByID(ctx context.Context, excusedAbsenceID int) (*ExcusedAbsence, error)
+	CreateExcusedAbsence(ctx context.Context, payload *ExcusedAbsencePayload, userID int) (*ExcusedAbsence, error)
+	UpdateExcusedAbsence(ctx context.Context, excusedAbsenceID int, payload *ExcusedAbsencePayload) error
+	DeleteExcusedAbsence(ctx context.Context, excusedAbsenceID int) error
+	ReviewExcusedAbsence(ctx context.Context, excusedAbsenceID int, payload *ExcuseReviewPayload, userID int) (*ExcusedAbsence, error)
+	SubmitParentExcuse(ctx context.Context, reply *ParentReply) (*ExcusedAbsence, error)
+}
+
+type ExcusedAbsenceUseCase interface {
+	GetAllExcusedAbsences(ctx context.Context, studentNSN, status string) (*[]ExcusedAbsence, error)
+	GetExcusedAbsenceByID(ctx context.Context, excusedAbsenceID int) (*ExcusedAbsence, error)
+	CreateExcusedAbsence(ctx context.Context, payload *ExcusedAbsencePayload, userID int) (*ExcusedAbsence, error)
+	UpdateExcusedAbsence(ctx context.Context, excusedAbsenceID int, payload *ExcusedAbsencePayload) error
+	DeleteExcusedAbsence(ctx context.Context, excusedAbsenceID int) error
+	ReviewExcusedAbsence(ctx context.Context, excusedAbsenceID int, payload *ExcuseReviewPayload, userID int) (*ExcusedAbsence, error)
+}
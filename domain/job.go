@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+const (
+	JobStatusQueued              = "queued"
+	JobStatusInProgress          = "in_progress"
+	JobStatusCompleted           = "completed"
+	JobStatusCompletedWithErrors = "completed_with_errors"
+)
+
+// NotificationJob groups the outbox messages queued by a single bulk send request.
+type NotificationJob struct {
+	JobID           string    `gorm:"primaryKey;type:varchar(36)" json:"job_id"`
+	EventType       string    `gorm:"type:varchar(30);not null" json:"event_type"`
+	IdempotencyKey  *string   `gorm:"type:varchar(100);uniqueIndex" json:"idempotency_key"`
+	UserID          *int      `json:"user_id"`
+	TotalRecipients int       `gorm:"not null;default:0" json:"total_recipients"`
+	TotalMessages   int       `gorm:"not null;default:0" json:"total_messages"`
+	Skipped         []string  `gorm:"type:text;serializer:json" json:"skipped"`
+	Duplicates      []string  `gorm:"type:text;serializer:json" json:"duplicates"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type JobChannelOutcome struct {
+	OutboxMessageID int        `json:"outbox_message_id"`
+	Channel         string     `json:"channel"`
+	Status          string     `json:"status"`
+	Attempts        int        `json:"attempts"`
+	LastError       *string    `json:"last_error"`
+	SentAt          *time.Time `json:"sent_at"`
+}
+
+type JobRecipientStatus struct {
+	ParentID      int                 `json:"parent_id"`
+	StudentNSN    string              `json:"student_nsn"`
+	RecipientName string              `json:"recipient_name"`
+	Channels      []JobChannelOutcome `json:"channels"`
+}
+
+type NotificationJobStatus struct {
+	Job        NotificationJob      `json:"job"`
+	Status     string               `json:"status"`
+	Pending    int                  `json:"pending"`
+	Sent       int                  `json:"sent"`
+	Failed     int                  `json:"failed"`
+	Recipients []JobRecipientStatus `json:"recipients"`
+}
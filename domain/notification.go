@@ -6,22 +6,51 @@ import (
 )
 
 type AttendanceNotificationHistory struct {
-	NotificationHistoryID int       `gorm:"primaryKey;autoIncrement" json:"notification_history_id"`
-	SubjectCode           string    `gorm:"not null" json:"subject_code"`
-	Subject               Subject   `gorm:"foreignKey:SubjectCode;references:SubjectCode;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"subject"`
-	StudentNSN            string    `gorm:"not null" json:"student_nsn"`
-	Student               Student   `gorm:"foreignKey:StudentNSN;references:StudentNSN;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"student"` // ✅ Ensures StudentNSN updates
-	ParentID              int       `gorm:"not null;index" json:"parent_id"`
-	Parent                Parent    `gorm:"foreignKey:ParentID;references:ParentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"parent"`
-	UserID                int       `gorm:"not null" json:"user_id"`
-	User                  User      `gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"user"`
-	WhatsappStatus        bool      `gorm:"not null" json:"whatsapp"`
-	EmailStatus           bool      `gorm:"not null" json:"email"`
-	CreatedAt             time.Time `gorm:"autoCreateTime" json:"created_at"`
+	NotificationHistoryID int        `gorm:"primaryKey;autoIncrement" json:"notification_history_id"`
+	AttendanceID          *int       `gorm:"index" json:"attendance_id"`
+	JobID                 *string    `gorm:"type:varchar(36);index" json:"job_id"`
+	SubjectCode           string     `gorm:"not null" json:"subject_code"`
+	Subject               Subject    `gorm:"foreignKey:SubjectCode;references:SubjectCode;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"subject"`
+	StudentNSN            string     `gorm:"not null" json:"student_nsn"`
+	Student               Student    `gorm:"foreignKey:StudentNSN;references:StudentNSN;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"student"` // ✅ Ensures StudentNSN updates
+	ParentID              int        `gorm:"not null;index" json:"parent_id"`
+	Parent                Parent     `gorm:"foreignKey:ParentID;references:ParentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"parent"`
+	UserID                int        `gorm:"not null" json:"user_id"`
+	User                  User       `gorm:"foreignKey:UserID;references:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"user"`
+	WhatsappStatus        bool       `gorm:"not null" json:"whatsapp"`
+	WhatsappMessageID     *string    `gorm:"type:varchar(64);index" json:"whatsapp_message_id"`
+	WhatsappSentAt        *time.Time `json:"whatsapp_sent_at"`
+	WhatsappDeliveredAt   *time.Time `json:"whatsapp_delivered_at"`
+	WhatsappReadAt        *time.Time `json:"whatsapp_read_at"`
+	EmailStatus           bool       `gorm:"not null" json:"email"`
+	SMSStatus             bool       `gorm:"column:sms_status;not null;default:false" json:"sms"`
+	CreatedAt             time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+const (
+	WhatsAppDeliveryPending   = "pending"
+	WhatsAppDeliverySent      = "sent"
+	WhatsAppDeliveryDelivered = "delivered"
+	WhatsAppDeliveryRead      = "read"
+)
+
+// WhatsAppDelivery returns how far the WhatsApp message of the history row got.
+func (h AttendanceNotificationHistory) WhatsAppDelivery() string {
+	switch {
+	case h.WhatsappReadAt != nil:
+		return WhatsAppDeliveryRead
+	case h.WhatsappDeliveredAt != nil:
+		return WhatsAppDeliveryDelivered
+	case h.WhatsappStatus:
+		return WhatsAppDeliverySent
+	default:
+		return WhatsAppDeliveryPending
+	}
 }
 
 type NotificationRepo interface {
 	GetAllAttendanceNotificationHistory(ctx context.Context) (*[]AttendanceNotificationHistoryResponse, error)
+	RecordWhatsAppReceipt(ctx context.Context, messageIDs []string, status string, at time.Time) (unmatched []string, err error)
 }
 
 type NotificationUseCase interface {
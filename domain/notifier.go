@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	ChannelEmail    = "email"
+	ChannelWhatsApp = "whatsapp"
+	ChannelSMS      = "sms"
+)
+
+// Recipient is the addressable side of a notification, a notifier only picks
+// the field it needs (email address for email, telephone for WhatsApp and SMS).
+type Recipient struct {
+	Name      string  `json:"name"`
+	Telephone string  `json:"telephone"`
+	Email     *string `json:"email,omitempty"`
+}
+
+type Message struct {
+	Subject     string       `json:"subject"`
+	Body        string       `json:"body"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a file sent along with a message, as an email attachment or a WhatsApp document.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"-"`
+}
+
+// SendResult is what a channel reports back for a sent message, MessageID is the id the channel
+// tracks the message by (the WhatsApp message id, the Message-ID header of an email). Response keeps
+// the replies of the channel when it gives any, such as the SMTP reply codes per recipient.
+type SendResult struct {
+	MessageID string    `json:"message_id"`
+	SentAt    time.Time `json:"sent_at"`
+	Response  string    `json:"response,omitempty"`
+}
+
+// PermanentFailure is implemented by send errors that retrying cannot fix, such as a mailbox the
+// mail server rejected.
+type PermanentFailure interface {
+	Permanent() bool
+}
+
+// Throttled is returned by a notifier that cannot send soon enough, e.g. a rate limited channel whose
+// queue outlasts the outbox lease. Nothing was sent, the message is put back and tried after RetryAfter
+// without counting as an attempt.
+type Throttled struct {
+	RetryAfter time.Duration
+}
+
+func (e *Throttled) Error() string {
+	return fmt.Sprintf("channel is throttled, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Notifier delivers a message to a recipient through a single channel.
+type Notifier interface {
+	Channel() string
+	Send(ctx context.Context, recipient Recipient, message Message) (*SendResult, error)
+}
+
+// NotifierRegistry holds the notifiers enabled for this deployment, keyed by channel name.
+type NotifierRegistry interface {
+	Register(notifier Notifier)
+	Get(channel string) (Notifier, bool)
+	Channels() []string
+}
+
+// HealthChecker is implemented by notifiers whose connection can drop. The outbox holds the
+// messages of an unhealthy channel until it recovers instead of spending their attempts.
+type HealthChecker interface {
+	Healthy() bool
+}
+
+const (
+	SMSBodyJSON = "json"
+	SMSBodyForm = "form"
+	SMSBodyNone = "none"
+)
+
+// SMSGateway describes the HTTP API of an SMS provider. URL may hold {to} and {message} placeholders,
+// unless BodyFormat is none the number and the text are also sent in a JSON or form body under ToField
+// and MessageField, together with the fixed Params (e.g. a sender id).
+type SMSGateway struct {
+	URL          string
+	Method       string
+	AuthHeader   string
+	AuthValue    string
+	BodyFormat   string
+	ToField      string
+	MessageField string
+	Params       map[string]string
+	Timeout      time.Duration
+}
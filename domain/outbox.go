@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	EventAbsence           = "absence"
+	EventAbsenceDigest     = "absence_digest"
+	EventAbsenceEscalation = "absence_escalation"
+	EventEscalationAlert   = "escalation_alert"
+	EventExamResult        = "exam_result"
+	EventExcuseReceived    = "excuse_received"
+	EventExcuseApproved    = "excuse_approved"
+	EventExcuseRejected    = "excuse_rejected"
+	EventExcuseTooLong     = "excuse_too_long"
+)
+
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusProcessing = "processing"
+	OutboxStatusSent       = "sent"
+	OutboxStatusDead       = "dead"
+)
+
+// OutboxMessage is a single rendered message waiting to be delivered through one channel.
+type OutboxMessage struct {
+	OutboxMessageID       int        `gorm:"primaryKey;autoIncrement" json:"outbox_message_id"`
+	JobID                 string     `gorm:"type:varchar(36);not null;index" json:"job_id"`
+	EventType             string     `gorm:"type:varchar(30);not null" json:"event_type"`
+	Channel               string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_outbox_fallback" json:"channel"`
+	ParentID              int        `gorm:"index" json:"parent_id"`
+	StudentNSN            string     `gorm:"type:varchar(10)" json:"student_nsn"`
+	RecipientName         string     `gorm:"type:varchar(150);not null" json:"recipient_name"`
+	RecipientTelephone    string     `gorm:"type:varchar(13)" json:"recipient_telephone"`
+	RecipientEmail        *string    `gorm:"type:varchar(255)" json:"recipient_email"`
+	Subject               string     `gorm:"type:text" json:"subject"`
+	Body                  string     `gorm:"type:text;not null" json:"body"`
+	AttachmentName        *string    `gorm:"type:varchar(255)" json:"attachment_name"`
+	AttachmentType        *string    `gorm:"type:varchar(100)" json:"attachment_type"`
+	Attachment            []byte     `gorm:"type:bytea" json:"-"`
+	Status                string     `gorm:"type:varchar(15);not null;default:pending;index" json:"status"`
+	NotificationHistoryID *int       `gorm:"index" json:"notification_history_id"`
+	FallbackOfID          *int       `gorm:"uniqueIndex:idx_outbox_fallback" json:"fallback_of_id"`
+	Attempts              int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt         *time.Time `gorm:"index" json:"next_attempt_at"`
+	LastError             *string    `gorm:"type:text" json:"last_error"`
+	Response              *string    `gorm:"type:text" json:"response"`
+	LockedUntil           *time.Time `json:"locked_until"`
+	SentAt                *time.Time `json:"sent_at"`
+	CreatedAt             time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt             time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (m *OutboxMessage) Recipient() Recipient {
+	return Recipient{
+		Name:      m.RecipientName,
+		Telephone: m.RecipientTelephone,
+		Email:     m.RecipientEmail,
+	}
+}
+
+func (m *OutboxMessage) Message() Message {
+	msg := Message{
+		Subject: m.Subject,
+		Body:    m.Body,
+	}
+
+	if m.AttachmentName != nil && len(m.Attachment) > 0 {
+		contentType := "application/octet-stream"
+		if m.AttachmentType != nil {
+			contentType = *m.AttachmentType
+		}
+		msg.Attachments = []Attachment{{
+			Filename:    *m.AttachmentName,
+			ContentType: contentType,
+			Data:        m.Attachment,
+		}}
+	}
+	return msg
+}
+
+// RetryPolicy decides how often a failed message is retried before it is marked dead.
+type RetryPolicy struct {
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// Backoff returns the delay before the next attempt, doubling per attempt up to BackoffMax.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BackoffBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= p.BackoffMax {
+			return p.BackoffMax
+		}
+	}
+	return delay
+}
+
+type OutboxRepo interface {
+	// DispatchNext claims one due message and sends it, reporting false when the outbox is empty.
+	DispatchNext(ctx context.Context) (bool, error)
+	GetDeadMessages(ctx context.Context) (*[]OutboxMessage, error)
+	RedriveDeadMessage(ctx context.Context, outboxMessageID int) error
+	RedriveDeadMessages(ctx context.Context, jobID string) (int64, error)
+}
+
+type OutboxUseCase interface {
+	DispatchNext(ctx context.Context) (bool, error)
+	GetDeadMessages(ctx context.Context) (*[]OutboxMessage, error)
+	RedriveDeadMessage(ctx context.Context, outboxMessageID int) error
+	RedriveDeadMessages(ctx context.Context, jobID string) (int64, error)
+}
@@ -5,12 +5,53 @@ import (
 )
 
 type Parent struct {
-	ParentID  int        `gorm:"primaryKey;autoIncrement" json:"parent_id"`
-	Name      string     `gorm:"type:varchar(150);not null;" json:"name" valid:"required~Name is required"`
-	Gender    string     `gorm:"type:gender_enum;not null" json:"gender" valid:"required~Gender is required,in(male|female|other)~Invalid gender"`
-	Telephone string     `gorm:"type:varchar(13);not null;" json:"telephone" valid:"required~Telephone is required"`
-	Email     *string    `gorm:"type:varchar(255)" json:"email" valid:"email~Invalid email format,optional"`
-	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt *time.Time `gorm:"index" json:"deleted_at"`
+	ParentID          int        `gorm:"primaryKey;autoIncrement" json:"parent_id"`
+	Name              string     `gorm:"type:varchar(150);not null;" json:"name" valid:"required~Name is required"`
+	Gender            string     `gorm:"type:gender_enum;not null" json:"gender" valid:"required~Gender is required,in(male|female|other)~Invalid gender"`
+	Telephone         string     `gorm:"type:varchar(13);not null;" json:"telephone" valid:"required~Telephone is required"`
+	Email             *string    `gorm:"type:varchar(255)" json:"email" valid:"email~Invalid email format,optional"`
+	PreferredLanguage *string    `gorm:"type:varchar(3)" json:"preferred_language" valid:"in(ind|eng)~Preferred language must be ind or eng,optional"`
+	NotifyEmail       *bool      `gorm:"not null;default:true" json:"notify_email"`
+	NotifyWhatsApp    *bool      `gorm:"column:notify_whatsapp;not null;default:true" json:"notify_whatsapp"`
+	NotifySMS         *bool      `gorm:"column:notify_sms;not null;default:true" json:"notify_sms"`
+	OptOutAbsence     *bool      `gorm:"not null;default:false" json:"opt_out_absence"`
+	OptOutExamResult  *bool      `gorm:"not null;default:false" json:"opt_out_exam_result"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt         *time.Time `gorm:"index" json:"deleted_at"`
+}
+
+// ParentNotificationPreferences is a partial update, nil fields are left as they are.
+type ParentNotificationPreferences struct {
+	NotifyEmail      *bool `json:"notify_email"`
+	NotifyWhatsApp   *bool `json:"notify_whatsapp"`
+	NotifySMS        *bool `json:"notify_sms"`
+	OptOutAbsence    *bool `json:"opt_out_absence"`
+	OptOutExamResult *bool `json:"opt_out_exam_result"`
+}
+
+// ChannelEnabled reports whether the parent still wants messages on the channel, unset means yes.
+func (p Parent) ChannelEnabled(channel string) bool {
+	switch channel {
+	case ChannelEmail:
+		return p.NotifyEmail == nil || *p.NotifyEmail
+	case ChannelWhatsApp:
+		return p.NotifyWhatsApp == nil || *p.NotifyWhatsApp
+	case ChannelSMS:
+		return p.NotifySMS == nil || *p.NotifySMS
+	default:
+		return true
+	}
+}
+
+// OptedOut reports whether the parent asked to stop receiving the event type.
+func (p Parent) OptedOut(eventType string) bool {
+	switch eventType {
+	case EventAbsence:
+		return p.OptOutAbsence != nil && *p.OptOutAbsence
+	case EventExamResult:
+		return p.OptOutExamResult != nil && *p.OptOutExamResult
+	default:
+		return false
+	}
 }
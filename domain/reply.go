@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// ParentReply is a WhatsApp message a parent sent to the school number, linked to the
+// notification it answers (the quoted one, otherwise the latest sent to the parent). A photo or
+// document, e.g. a doctor's note, is kept in Media and served separately from the reply list.
+type ParentReply struct {
+	ParentReplyID         int                            `gorm:"primaryKey;autoIncrement" json:"parent_reply_id"`
+	ParentID              int                            `gorm:"not null;index" json:"parent_id"`
+	Parent                Parent                         `gorm:"foreignKey:ParentID;references:ParentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"parent"`
+	NotificationHistoryID *int                           `gorm:"index" json:"notification_history_id"`
+	NotificationHistory   *AttendanceNotificationHistory `gorm:"foreignKey:NotificationHistoryID;references:NotificationHistoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL" json:"notification_history,omitempty"`
+	StudentNSN            *string                        `gorm:"type:varchar(10);index" json:"student_nsn"`
+	WhatsappMessageID     string                         `gorm:"type:varchar(64);not null;uniqueIndex" json:"whatsapp_message_id"`
+	SenderJID             string                         `gorm:"type:varchar(100);not null" json:"sender_jid"`
+	Body                  string                         `gorm:"type:text;not null" json:"body"`
+	MediaType             *string                        `gorm:"type:varchar(20)" json:"media_type"`
+	MediaFileName         *string                        `gorm:"type:varchar(255)" json:"media_file_name"`
+	MediaMimeType         *string                        `gorm:"type:varchar(100)" json:"media_mime_type"`
+	Media                 []byte                         `gorm:"type:bytea" json:"-"`
+	ReceivedAt            time.Time                      `gorm:"not null;index" json:"received_at"`
+	ReadAt                *time.Time                     `json:"read_at"`
+	ReadBy                *int                           `json:"read_by"`
+	CreatedAt             time.Time                      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// InboundMessage is a message received on the school number before it is matched to a parent.
+// Telephone is the sender's number in international form (628xx), QuotedMessageID the id of the
+// message it replies to, if any. Media holds the downloaded photo or document.
+type InboundMessage struct {
+	MessageID       string
+	SenderJID       string
+	Telephone       string
+	Body            string
+	MediaType       *string
+	MediaFileName   *string
+	MediaMimeType   *string
+	Media           []byte
+	QuotedMessageID *string
+	ReceivedAt      time.Time
+}
+
+type ParentReplyRepo interface {
+	RecordParentReply(ctx context.Context, message *InboundMessage) (*ParentReply, error)
+	GetAllParentReplies(ctx context.Context, unreadOnly bool) (*[]ParentReply, error)
+	GetParentReplyMedia(ctx context.Context, parentReplyID int) (*ParentReply, error)
+	MarkParentReplyRead(ctx context.Context, parentReplyID int, userID int) error
+}
+
+type ParentReplyUseCase interface {
+	GetAllParentReplies(ctx context.Context, unreadOnly bool) (*[]ParentReply, error)
+	GetParentReplyMedia(ctx context.Context, parentReplyID int) (*ParentReply, error)
+	MarkParentReplyRead(ctx context.Context, parentReplyID int, userID int) error
+}
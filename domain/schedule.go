@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ScheduleStatusPending   = "pending"
+	ScheduleStatusRunning   = "running"
+	ScheduleStatusDone      = "done"
+	ScheduleStatusFailed    = "failed"
+	ScheduleStatusCancelled = "cancelled"
+)
+
+// ScheduledNotification is a send-mass (absence) or exam result announcement to be run at RunAt.
+// The scheduler runs it under the idempotency key scheduled-<id>, so a run interrupted by a restart
+// is picked up again without notifying parents twice.
+type ScheduledNotification struct {
+	ScheduledNotificationID int        `gorm:"primaryKey;autoIncrement" json:"scheduled_notification_id"`
+	EventType               string     `gorm:"type:varchar(30);not null" json:"event_type"`
+	RunAt                   time.Time  `gorm:"not null;index" json:"run_at"`
+	AbsenceDate             *time.Time `gorm:"type:date" json:"absence_date"`
+	NSNList                 []string   `gorm:"type:text;serializer:json" json:"nsn_list"`
+	SubjectCode             *string    `gorm:"type:varchar(5)" json:"subject_code"`
+	ExamType                *string    `gorm:"type:varchar(50)" json:"exam_type"`
+	ReportCard              bool       `gorm:"not null;default:false" json:"report_card"`
+	UserID                  int        `gorm:"not null" json:"user_id"`
+	Status                  string     `gorm:"type:varchar(10);not null;default:pending;index" json:"status"`
+	JobID                   *string    `gorm:"type:varchar(36)" json:"job_id"`
+	LastError               *string    `gorm:"type:text" json:"last_error"`
+	LockedUntil             *time.Time `json:"locked_until"`
+	ExecutedAt              *time.Time `json:"executed_at"`
+	CreatedAt               time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt               time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ScheduledNotificationPayload schedules a send, RunAt is formatted as 2006-01-02 15:04 in the school's timezone.
+// Absence sends need NSNList and SubjectCode and record the absence on the day of RunAt, even when the run
+// is late. Exam result sends need ExamType and may attach a PDF report card.
+type ScheduledNotificationPayload struct {
+	EventType   string   `json:"event_type" valid:"required~Event type is required,in(absence|exam_result)~Event type must be absence or exam_result"`
+	RunAt       string   `json:"run_at" valid:"required~Run at is required"`
+	NSNList     []string `json:"nsn_list"`
+	SubjectCode string   `json:"subject_code"`
+	ExamType    string   `json:"exam_type"`
+	ReportCard  bool     `json:"report_card"`
+}
+
+type ScheduledNotificationRepo interface {
+	GetAllScheduledNotifications(ctx context.Context, status string) (*[]ScheduledNotification, error)
+	CreateScheduledNotification(ctx context.Context, payload *ScheduledNotificationPayload, userID int) (*ScheduledNotification, error)
+	CancelScheduledNotification(ctx context.Context, scheduledNotificationID int) error
+	RunNext(ctx context.Context) (bool, error)
+}
+
+type ScheduledNotificationUseCase interface {
+	GetAllScheduledNotifications(ctx context.Context, status string) (*[]ScheduledNotification, error)
+	CreateScheduledNotification(ctx context.Context, payload *ScheduledNotificationPayload, userID int) (*ScheduledNotification, error)
+	CancelScheduledNotification(ctx context.Context, scheduledNotificationID int) error
+	RunNext(ctx context.Context) (bool, error)
+}
@@ -1,13 +1,46 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// NotificationPreview is a rendered message as a parent would receive it on one channel.
+type NotificationPreview struct {
+	ParentID    int     `json:"parent_id"`
+	ParentName  string  `json:"parent_name"`
+	StudentNSN  string  `json:"student_nsn"`
+	StudentName string  `json:"student_name"`
+	Channel     string  `json:"channel"`
+	Telephone   string  `json:"telephone"`
+	Email       *string `json:"email"`
+	Subject     string  `json:"subject"`
+	Body        string  `json:"body"`
+}
+
+// NotificationPreviewResult is the outcome of a dry run, nothing is queued or marked as sent.
+type NotificationPreviewResult struct {
+	EventType string                `json:"event_type"`
+	Messages  []NotificationPreview `json:"messages"`
+	Skipped   []string              `json:"skipped"`
+}
 
 type SenderRepo interface {
-	SendMass(ctx context.Context, nsnList *[]string, userID *int, subjectCode string) error
-	SendTestScores(ctx context.Context, examType string) error
+	SendMass(ctx context.Context, nsnList *[]string, userID *int, subjectCode string, date *time.Time, idempotencyKey *string) (*NotificationJob, error)
+	SendRollCall(ctx context.Context, rollCall *RollCall, userID *int) (*RollCallResult, error)
+	SendDigest(ctx context.Context) (*NotificationJob, error)
+	SendTestScores(ctx context.Context, examType string, reportCard bool, userID *int, idempotencyKey *string) (*NotificationJob, error)
+	GetJobStatus(ctx context.Context, jobID string) (*NotificationJobStatus, error)
+	PreviewMass(ctx context.Context, nsnList *[]string, subjectCode string) (*NotificationPreviewResult, error)
+	PreviewTestScores(ctx context.Context, examType string) (*NotificationPreviewResult, error)
 }
 
 type SenderUseCase interface {
-	SendMass(ctx context.Context, nsnList *[]string, userID *int, subjectCode string) error
-	SendTestScores(ctx context.Context, examType string) error
+	SendMass(ctx context.Context, nsnList *[]string, userID *int, subjectCode string, date *time.Time, idempotencyKey *string) (*NotificationJob, error)
+	SendRollCall(ctx context.Context, rollCall *RollCall, userID *int) (*RollCallResult, error)
+	SendDigest(ctx context.Context) (*NotificationJob, error)
+	SendTestScores(ctx context.Context, examType string, reportCard bool, userID *int, idempotencyKey *string) (*NotificationJob, error)
+	GetJobStatus(ctx context.Context, jobID string) (*NotificationJobStatus, error)
+	PreviewMass(ctx context.Context, nsnList *[]string, subjectCode string) (*NotificationPreviewResult, error)
+	PreviewTestScores(ctx context.Context, examType string) (*NotificationPreviewResult, error)
 }
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	AbsenceModeImmediate = "immediate"
+	AbsenceModeDigest    = "digest"
+)
+
+// SchoolSettingID is the primary key of the single school_settings row.
+const SchoolSettingID = 1
+
+// SchoolSetting holds the per school notification behaviour, there is exactly one row.
+// Times are wall clock times in Timezone, an IANA name or Local for the server's zone.
+// Outbox messages are held while the clock is between QuietHoursStart and QuietHoursEnd,
+// SchoolDays lists the weekdays lessons are held on with 0 as Sunday.
+type SchoolSetting struct {
+	SchoolSettingID int        `gorm:"primaryKey" json:"school_setting_id"`
+	AbsenceMode     string     `gorm:"type:varchar(10);not null;default:'immediate'" json:"absence_mode"`
+	DigestCutoff    string     `gorm:"type:varchar(5);not null;default:'15:00'" json:"digest_cutoff"`
+	LastDigestDate  *time.Time `gorm:"type:date" json:"last_digest_date"`
+	Timezone        string     `gorm:"type:varchar(50);not null;default:'Local'" json:"timezone"`
+	QuietHoursStart *string    `gorm:"type:varchar(5)" json:"quiet_hours_start"`
+	QuietHoursEnd   *string    `gorm:"type:varchar(5)" json:"quiet_hours_end"`
+	SchoolDays      string     `gorm:"type:varchar(20);not null;default:'1,2,3,4,5'" json:"school_days"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SchoolSettingPayload is a partial update, nil fields are left as they are. Times are formatted as 15:04,
+// empty quiet hours turn them off and SchoolDays is a comma separated list of weekdays.
+type SchoolSettingPayload struct {
+	AbsenceMode     *string `json:"absence_mode" valid:"in(immediate|digest)~Absence mode must be immediate or digest,optional"`
+	DigestCutoff    *string `json:"digest_cutoff" valid:"optional"`
+	Timezone        *string `json:"timezone" valid:"optional"`
+	QuietHoursStart *string `json:"quiet_hours_start" valid:"optional"`
+	QuietHoursEnd   *string `json:"quiet_hours_end" valid:"optional"`
+	SchoolDays      *string `json:"school_days" valid:"optional"`
+}
+
+// SchoolHoliday is a range of non-school days from StartDate to EndDate inclusive, e.g. a semester break.
+type SchoolHoliday struct {
+	SchoolHolidayID int       `gorm:"primaryKey;autoIncrement" json:"school_holiday_id"`
+	Name            string    `gorm:"type:varchar(100);not null" json:"name"`
+	StartDate       time.Time `gorm:"type:date;not null;index" json:"start_date"`
+	EndDate         time.Time `gorm:"type:date;not null;index" json:"end_date"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// SchoolHolidayPayload carries dates formatted as 2006-01-02.
+type SchoolHolidayPayload struct {
+	Name      string `json:"name" valid:"required~Name is required"`
+	StartDate string `json:"start_date" valid:"required~Start date is required"`
+	EndDate   string `json:"end_date" valid:"required~End date is required"`
+}
+
+type SchoolSettingRepo interface {
+	GetSchoolSetting(ctx context.Context) (*SchoolSetting, error)
+	UpdateSchoolSetting(ctx context.Context, payload *SchoolSettingPayload) (*SchoolSetting, error)
+	GetAllSchoolHolidays(ctx context.Context) (*[]SchoolHoliday, error)
+	CreateSchoolHoliday(ctx context.Context, payload *SchoolHolidayPayload) (*SchoolHoliday, error)
+	DeleteSchoolHoliday(ctx context.Context, schoolHolidayID int) error
+}
+
+type SchoolSettingUseCase interface {
+	GetSchoolSetting(ctx context.Context) (*SchoolSetting, error)
+	UpdateSchoolSetting(ctx context.Context, payload *SchoolSettingPayload) (*SchoolSetting, error)
+	GetAllSchoolHolidays(ctx context.Context) (*[]SchoolHoliday, error)
+	CreateSchoolHoliday(ctx context.Context, payload *SchoolHolidayPayload) (*SchoolHoliday, error)
+	DeleteSchoolHoliday(ctx context.Context, schoolHolidayID int) error
+}
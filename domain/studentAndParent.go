@@ -19,6 +19,7 @@ type ParentDataChangeRequest struct {
 	NewParentTelephone *string    `json:"new_parent_telephone,omitempty"`
 	NewParentEmail     *string    `json:"new_parent_email,omitempty"`
 	NewParentGender    *string    `gorm:"type:gender_enum" json:"new_parent_gender" valid:"required~Gender is required,in(male|female)~Invalid gender"`
+	NewParentLanguage  *string    `gorm:"type:varchar(3)" json:"new_parent_language,omitempty"`
 	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	IsReviewed         bool       `gorm:"default:false" json:"is_reviewed"`
 	DeletedAt          *time.Time `gorm:"index" json:"deleted_at"`
@@ -38,6 +39,7 @@ type StudentParentRepo interface {
 	DataChangeRequest(ctx context.Context, datas ParentDataChangeRequest, userID int) error
 	ApproveDCR(ctx context.Context, req map[string]interface{}) (*string, error)
 	DeleteDCR(ctx context.Context, dcrID int) error
+	UpdateParentPreferences(ctx context.Context, parentID int, prefs *ParentNotificationPreferences) (*Parent, error)
 }
 
 type StudentParentUseCase interface {
@@ -54,4 +56,5 @@ type StudentParentUseCase interface {
 	DataChangeRequest(ctx context.Context, datas ParentDataChangeRequest, userID int) error
 	ApproveDCR(ctx context.Context, req map[string]interface{}) (*string, error)
 	DeleteDCR(ctx context.Context, dcrID int) error
+	UpdateParentPreferences(ctx context.Context, parentID int, prefs *ParentNotificationPreferences) (*Parent, error)
 }
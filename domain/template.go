@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	LanguageIndonesian = "ind"
+	LanguageEnglish    = "eng"
+)
+
+func IsSupportedLanguage(language string) bool {
+	return language == LanguageIndonesian || language == LanguageEnglish
+}
+
+// MessageTemplate is a text/template source for one (event type, language, channel) combination.
+type MessageTemplate struct {
+	TemplateID int       `gorm:"primaryKey;autoIncrement" json:"template_id"`
+	EventType  string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_message_template_key" json:"event_type" valid:"required~Event type is required"`
+	Language   string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_message_template_key" json:"language" valid:"required~Language is required,in(ind|eng)~Language must be ind or eng"`
+	Channel    string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_message_template_key" json:"channel" valid:"required~Channel is required"`
+	Subject    string    `gorm:"type:text" json:"subject"`
+	Body       string    `gorm:"type:text;not null" json:"body" valid:"required~Body is required"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TemplateData is what message templates are rendered against.
+type TemplateData struct {
+	Student     Student                 `json:"student"`
+	Parent      Parent                  `json:"parent"`
+	Subject     Subject                 `json:"subject"`
+	Subjects    []Subject               `json:"subjects"`
+	Absences    int                     `json:"absences"`
+	Sessions    int                     `json:"sessions"`
+	Period      string                  `json:"period"`
+	Days        int                     `json:"days"`
+	StartDate   string                  `json:"start_date"`
+	EndDate     string                  `json:"end_date"`
+	Reason      string                  `json:"reason"`
+	Note        string                  `json:"note"`
+	Session     int                     `json:"session"`
+	ExamType    string                  `json:"exam_type"`
+	Scores      []SubjectAndScoreResult `json:"scores"`
+	Date        string                  `json:"date"`
+	Time        string                  `json:"time"`
+	Meridiem    string                  `json:"meridiem"`
+	SchoolPhone string                  `json:"school_phone"`
+}
+
+type MessageTemplateRepo interface {
+	GetAllTemplates(ctx context.Context) (*[]MessageTemplate, error)
+	GetTemplateByID(ctx context.Context, templateID int) (*MessageTemplate, error)
+	CreateTemplate(ctx context.Context, template *MessageTemplate) error
+	UpdateTemplate(ctx context.Context, templateID int, template *MessageTemplate) error
+	DeleteTemplate(ctx context.Context, templateID int) error
+}
+
+type MessageTemplateUseCase interface {
+	GetAllTemplates(ctx context.Context) (*[]MessageTemplate, error)
+	GetTemplateByID(ctx context.Context, templateID int) (*MessageTemplate, error)
+	CreateTemplate(ctx context.Context, template *MessageTemplate) error
+	UpdateTemplate(ctx context.Context, templateID int, template *MessageTemplate) error
+	DeleteTemplate(ctx context.Context, templateID int) error
+}
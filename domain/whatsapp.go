@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	WhatsAppEventQR          = "qr"
+	WhatsAppEventQRTimeout   = "qr_timeout"
+	WhatsAppEventPairSuccess = "pair_success"
+	WhatsAppEventPairError   = "pair_error"
+	WhatsAppEventConnected   = "connected"
+	WhatsAppEventDisconnect  = "disconnected"
+	WhatsAppEventReconnect   = "reconnecting"
+	WhatsAppEventLoggedOut   = "logged_out"
+	WhatsAppEventReplaced    = "replaced"
+)
+
+// WhatsAppStatus describes the WhatsApp session of the school number.
+// Paired means a device is linked, Connected that the websocket is up and LoggedIn that it is usable for sending.
+type WhatsAppStatus struct {
+	Paired      bool       `json:"paired"`
+	Connected   bool       `json:"connected"`
+	LoggedIn    bool       `json:"logged_in"`
+	Pairing     bool       `json:"pairing"`
+	JID         *string    `json:"jid"`
+	QRAvailable bool       `json:"qr_available"`
+	LastEvent   string     `json:"last_event"`
+	LastEventAt *time.Time `json:"last_event_at"`
+}
+
+// WhatsAppQR is the pairing code currently shown to link the school number, PNG is base64 encoded.
+type WhatsAppQR struct {
+	Code      string    `json:"code"`
+	PNG       string    `json:"png"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type WhatsAppEvent struct {
+	Event  string    `json:"event"`
+	Detail string    `json:"detail,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+type WhatsAppSessionRepo interface {
+	Start(ctx context.Context) error
+	GetStatus(ctx context.Context) (*WhatsAppStatus, error)
+	GetQR(ctx context.Context) (*WhatsAppQR, error)
+	Pair(ctx context.Context) error
+	Logout(ctx context.Context) error
+	Subscribe() (<-chan WhatsAppEvent, func())
+}
+
+type WhatsAppSessionUseCase interface {
+	GetStatus(ctx context.Context) (*WhatsAppStatus, error)
+	GetQR(ctx context.Context) (*WhatsAppQR, error)
+	Pair(ctx context.Context) error
+	Logout(ctx context.Context) error
+	Subscribe() (<-chan WhatsAppEvent, func())
+}
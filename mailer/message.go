@@ -0,0 +1,245 @@
+// Package mailer builds and sends email: MIME messages with an HTML and a plain text version,
+// encoded headers and attachments.
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Attachment is a file added to a message as a multipart/mixed part.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message is an email before it is encoded. Text is required, HTML is optional and turns the body
+// into multipart/alternative so clients that cannot show HTML fall back to the text.
+// A zero Date and an empty MessageID are filled in when the message is built.
+type Message struct {
+	MessageID   string
+	From        mail.Address
+	To          []mail.Address
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+	Date        time.Time
+}
+
+// Recipients returns the bare addresses of To, as needed for the SMTP envelope.
+func (m *Message) Recipients() []string {
+	recipients := make([]string, 0, len(m.To))
+	for _, to := range m.To {
+		recipients = append(recipients, to.Address)
+	}
+	return recipients
+}
+
+// Bytes encodes the message as RFC 5322 with MIME bodies: headers are RFC 2047 encoded when they
+// are not plain ASCII, text parts are quoted-printable and attachments base64.
+func (m *Message) Bytes() ([]byte, error) {
+	if m.From.Address == "" {
+		return nil, fmt.Errorf("message has no sender")
+	}
+	if len(m.To) == 0 {
+		return nil, fmt.Errorf("message has no recipient")
+	}
+
+	if m.Date.IsZero() {
+		m.Date = time.Now()
+	}
+	if m.MessageID == "" {
+		messageID, err := newMessageID(m.From.Address)
+		if err != nil {
+			return nil, err
+		}
+		m.MessageID = messageID
+	}
+
+	to := make([]string, 0, len(m.To))
+	for _, addr := range m.To {
+		to = append(to, addr.String())
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", m.From.String())
+	writeHeader(&buf, "To", strings.Join(to, ", "))
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("UTF-8", m.Subject))
+	writeHeader(&buf, "Date", m.Date.Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", m.MessageID)
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	if len(m.Attachments) == 0 {
+		if err := m.writeBody(&buf, nil); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	writeHeader(&buf, "Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	buf.WriteString("\r\n")
+
+	if err := m.writeBody(&buf, mixed); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range m.Attachments {
+		if err := writeAttachment(mixed, attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close multipart body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBody writes the text, or the text and HTML alternatives, either straight after the message
+// headers or as the first part of parent.
+func (m *Message) writeBody(buf *bytes.Buffer, parent *multipart.Writer) error {
+	if m.HTML == "" {
+		return writeTextPart(buf, parent, "text/plain; charset=UTF-8", m.Text)
+	}
+
+	var body bytes.Buffer
+	alternative := multipart.NewWriter(&body)
+	if err := writeTextPart(nil, alternative, "text/plain; charset=UTF-8", m.Text); err != nil {
+		return err
+	}
+	if err := writeTextPart(nil, alternative, "text/html; charset=UTF-8", m.HTML); err != nil {
+		return err
+	}
+	if err := alternative.Close(); err != nil {
+		return fmt.Errorf("failed to close alternative body: %w", err)
+	}
+
+	contentType := "multipart/alternative; boundary=" + alternative.Boundary()
+	if parent == nil {
+		writeHeader(buf, "Content-Type", contentType)
+		buf.WriteString("\r\n")
+		buf.Write(body.Bytes())
+		return nil
+	}
+
+	part, err := parent.CreatePart(textproto.MIMEHeader{"Content-Type": {contentType}})
+	if err != nil {
+		return fmt.Errorf("failed to create alternative part: %w", err)
+	}
+	_, err = part.Write(body.Bytes())
+	return err
+}
+
+// writeTextPart writes content quoted-printable, as a part of parent or, without parent, straight into buf.
+func writeTextPart(buf *bytes.Buffer, parent *multipart.Writer, contentType, content string) error {
+	var w io.Writer
+	if parent == nil {
+		writeHeader(buf, "Content-Type", contentType)
+		writeHeader(buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		w = buf
+	} else {
+		part, err := parent.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create %s part: %w", contentType, err)
+		}
+		w = part
+	}
+
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(normalizeNewlines(content))); err != nil {
+		return fmt.Errorf("failed to encode %s part: %w", contentType, err)
+	}
+	return qp.Close()
+}
+
+func writeAttachment(parent *multipart.Writer, attachment Attachment) error {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	part, err := parent.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": attachment.Filename})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach %s: %w", attachment.Filename, err)
+	}
+
+	// Mail lines are limited in length, base64 is wrapped at 76 characters
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(part, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = io.WriteString(part, encoded+"\r\n")
+	return err
+}
+
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name + ": " + value + "\r\n")
+}
+
+// newMessageID returns a unique Message-ID on the sender's domain.
+func newMessageID(from string) (string, error) {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %w", err)
+	}
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain), nil
+}
+
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+// HTMLFromText renders a plain text body as simple HTML: the text is escaped, blank lines start a new
+// paragraph and single line breaks are kept, so templates written for WhatsApp read well in an email.
+func HTMLFromText(text string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"UTF-8\"></head>\n")
+	b.WriteString("<body style=\"font-family: Arial, Helvetica, sans-serif; font-size: 14px; line-height: 1.5; color: #222;\">\n")
+
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.Trim(paragraph, "\n")
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i := range lines {
+			lines[i] = html.EscapeString(lines[i])
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>\n") + "</p>\n")
+	}
+
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
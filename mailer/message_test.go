@@ -0,0 +1,149 @@
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func testMessage() *Message {
+	return &Message{
+		From:    mail.Address{Name: "Sekolah", Address: "school@example.com"},
+		To:      []mail.Address{{Name: "Budi", Address: "budi@example.com"}},
+		Subject: "Ketidakhadiran Siswa – Budi",
+		Text:    "Hello\nworld",
+		HTML:    HTMLFromText("Hello\nworld"),
+	}
+}
+
+func TestMessageEncodesNonASCIISubject(t *testing.T) {
+	data, err := testMessage().Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+
+	raw := parsed.Header.Get("Subject")
+	if !strings.HasPrefix(raw, "=?UTF-8?q?") {
+		t.Errorf("subject %q is not Q-encoded", raw)
+	}
+
+	decoded, err := new(mime.WordDecoder).DecodeHeader(raw)
+	if err != nil {
+		t.Fatalf("DecodeHeader: %v", err)
+	}
+	if decoded != "Ketidakhadiran Siswa – Budi" {
+		t.Errorf("subject decodes to %q", decoded)
+	}
+
+	if parsed.Header.Get("Message-ID") == "" || parsed.Header.Get("Date") == "" {
+		t.Errorf("Message-ID and Date must be filled in")
+	}
+}
+
+func TestMessageStructure(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachments []Attachment
+		// want lists the media types of the message and its parts, depth first
+		want []string
+	}{
+		{
+			name: "alternative only",
+			want: []string{"multipart/alternative", "text/plain", "text/html"},
+		},
+		{
+			name:        "mixed with attachment",
+			attachments: []Attachment{{Filename: "rapor.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")}},
+			want:        []string{"multipart/mixed", "multipart/alternative", "text/plain", "text/html", "application/pdf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := testMessage()
+			message.Attachments = tt.attachments
+
+			data, err := message.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes: %v", err)
+			}
+			parsed, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ReadMessage: %v", err)
+			}
+
+			var got []string
+			collectParts(t, parsed.Header.Get("Content-Type"), parsed.Body, &got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("parts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// collectParts walks a MIME body depth first and appends the media type of every part.
+func collectParts(t *testing.T, contentType string, body io.Reader, got *[]string) {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("ParseMediaType(%q): %v", contentType, err)
+	}
+	*got = append(*got, mediaType)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return
+	}
+
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		collectParts(t, part.Header.Get("Content-Type"), part, got)
+	}
+}
+
+func TestAttachmentBase64WrapsAt76Columns(t *testing.T) {
+	message := testMessage()
+	message.Attachments = []Attachment{{Filename: "rapor.pdf", ContentType: "application/pdf", Data: bytes.Repeat([]byte{0xff, 0x00, 0x7f}, 200)}}
+
+	data, err := message.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// The attachment is the last part, its body runs from the blank line after its headers to the closing boundary
+	start := bytes.LastIndex(data, []byte("Content-Transfer-Encoding: base64"))
+	if start < 0 {
+		t.Fatalf("no base64 part in message")
+	}
+	body := data[start:]
+	body = body[bytes.Index(body, []byte("\r\n\r\n"))+4:]
+	body = body[:bytes.Index(body, []byte("\r\n--"))]
+
+	lines := strings.Split(strings.TrimSuffix(string(body), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("attachment of 600 bytes was not wrapped: %q", body)
+	}
+	for i, line := range lines {
+		if len(line) > 76 {
+			t.Errorf("line %d is %d characters long", i, len(line))
+		}
+		if i < len(lines)-1 && len(line) != 76 {
+			t.Errorf("line %d is %d characters long, only the last line may be shorter than 76", i, len(line))
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TLSImplicit opens the connection over TLS right away, usually on port 465.
+	TLSImplicit = "tls"
+	// TLSStartTLS upgrades a plain connection with STARTTLS and fails if the server does not offer it, usually on port 587.
+	TLSStartTLS = "starttls"
+	// TLSNone never encrypts, only meant for a relay on the local network. Most servers refuse to authenticate without TLS.
+	TLSNone = "none"
+)
+
+// Config describes the SMTP server a Transport sends through.
+type Config struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	TLS      string
+	// DialTimeout bounds connecting, the TLS handshake included.
+	DialTimeout time.Duration
+	// SendTimeout bounds a whole message exchange on an open connection.
+	SendTimeout time.Duration
+	// IdleTimeout is how long an unused connection is kept open for the next message.
+	IdleTimeout time.Duration
+	// MaxConnections is the number of messages sent at the same time, further senders wait for a free connection.
+	MaxConnections int
+}
+
+// RecipientStatus is the reply of the server to one recipient of a message.
+type RecipientStatus struct {
+	Address string `json:"address"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Accepted reports whether the server will deliver to the recipient.
+func (s RecipientStatus) Accepted() bool {
+	return s.Code >= 200 && s.Code < 300
+}
+
+// Result is the outcome of a sent message: the final reply to its data and the reply per recipient.
+type Result struct {
+	MessageID  string
+	Code       int
+	Response   string
+	Recipients []RecipientStatus
+}
+
+// SMTPError is a negative reply of the server to a recipient or to the message data, Address is set
+// when it rejected a single recipient. Failures to connect, secure the connection or authenticate are
+// plain errors on purpose: they come from the setup, not the message, and must stay retryable.
+type SMTPError struct {
+	Address string
+	Code    int
+	Message string
+}
+
+func (e *SMTPError) Error() string {
+	if e.Address != "" {
+		return fmt.Sprintf("smtp server rejected %s: %d %s", e.Address, e.Code, e.Message)
+	}
+	return fmt.Sprintf("smtp server replied %d %s", e.Code, e.Message)
+}
+
+// Permanent reports whether sending again cannot succeed (5xx replies), 4xx replies are worth a retry.
+func (e *SMTPError) Permanent() bool {
+	return e.Code >= 500
+}
+
+// Transport sends messages over a small pool of SMTP connections which stay open between messages,
+// so a batch of notifications does not pay a TCP and TLS handshake per parent.
+type Transport struct {
+	config Config
+	slots  chan struct{}
+
+	mu     sync.Mutex
+	idle   []*smtpConn
+	closed bool
+}
+
+type smtpConn struct {
+	client   *smtp.Client
+	raw      net.Conn
+	lastUsed time.Time
+}
+
+func NewTransport(config Config) *Transport {
+	if config.TLS == "" {
+		config.TLS = TLSStartTLS
+		if config.Port == "465" {
+			config.TLS = TLSImplicit
+		}
+	}
+	if config.MaxConnections <= 0 {
+		config.MaxConnections = 1
+	}
+
+	return &Transport{
+		config: config,
+		slots:  make(chan struct{}, config.MaxConnections),
+	}
+}
+
+// Send delivers the message to its recipients. The error is nil as long as the server accepted the
+// message for at least one recipient, the replies for the others are in the result.
+func (t *Transport) Send(ctx context.Context, message *Message) (*Result, error) {
+	data, err := message.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case t.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-t.slots }()
+
+	conn, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := conn.send(ctx, t.config.SendTimeout, message, data)
+
+	// A negative reply leaves the session usable, anything else (a timeout, a dropped connection) does not
+	var smtpErr *SMTPError
+	if err != nil && !errors.As(err, &smtpErr) {
+		conn.close()
+		return result, err
+	}
+
+	t.release(conn)
+	return result, err
+}
+
+// Close quits every idle connection, connections still sending are closed when they are done.
+func (t *Transport) Close() {
+	t.mu.Lock()
+	idle := t.idle
+	t.idle = nil
+	t.closed = true
+	t.mu.Unlock()
+
+	for _, conn := range idle {
+		conn.quit()
+	}
+}
+
+// acquire takes the most recently used idle connection that is still alive or dials a new one.
+func (t *Transport) acquire(ctx context.Context) (*smtpConn, error) {
+	for {
+		t.mu.Lock()
+		if len(t.idle) == 0 {
+			t.mu.Unlock()
+			break
+		}
+		conn := t.idle[len(t.idle)-1]
+		t.idle = t.idle[:len(t.idle)-1]
+		t.mu.Unlock()
+
+		if t.config.IdleTimeout > 0 && time.Since(conn.lastUsed) > t.config.IdleTimeout {
+			conn.quit()
+			continue
+		}
+
+		// RSET both clears the previous transaction and tells whether the server hung up meanwhile
+		conn.raw.SetDeadline(time.Now().Add(t.dialTimeout()))
+		if err := conn.client.Reset(); err != nil {
+			conn.close()
+			continue
+		}
+		return conn, nil
+	}
+
+	return t.dial(ctx)
+}
+
+func (t *Transport) release(conn *smtpConn) {
+	conn.lastUsed = time.Now()
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		conn.quit()
+		return
+	}
+	t.idle = append(t.idle, conn)
+	t.mu.Unlock()
+}
+
+func (t *Transport) dialTimeout() time.Duration {
+	if t.config.DialTimeout > 0 {
+		return t.config.DialTimeout
+	}
+	return 30 * time.Second
+}
+
+// dial connects, secures the connection as configured and authenticates with the best mechanism
+// the server offers.
+func (t *Transport) dial(ctx context.Context) (*smtpConn, error) {
+	address := net.JoinHostPort(t.config.Host, t.config.Port)
+	deadline := time.Now().Add(t.dialTimeout())
+
+	dialCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	raw, err := dialer.DialContext(dialCtx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server %s: %w", address, err)
+	}
+
+	if t.config.TLS == TLSImplicit {
+		tlsConn := tls.Client(raw, &tls.Config{ServerName: t.config.Host})
+		if err := tlsConn.HandshakeContext(dialCtx); err != nil {
+			raw.Close()
+			return nil, fmt.Errorf("tls handshake with smtp server %s failed: %w", address, err)
+		}
+		raw = tlsConn
+	}
+
+	// The greeting, EHLO, STARTTLS and AUTH all share the dial deadline
+	raw.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(raw, t.config.Host)
+	if err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("failed to greet smtp server %s: %v", address, err)
+	}
+	conn := &smtpConn{client: client, raw: raw}
+
+	if t.config.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			conn.close()
+			return nil, fmt.Errorf("smtp server %s does not support STARTTLS", address)
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: t.config.Host}); err != nil {
+			conn.close()
+			return nil, fmt.Errorf("STARTTLS with smtp server %s failed: %v", address, err)
+		}
+	}
+
+	if t.config.Username != "" {
+		ok, mechanisms := client.Extension("AUTH")
+		if !ok {
+			conn.close()
+			return nil, fmt.Errorf("smtp server %s does not support authentication", address)
+		}
+
+		auth, err := t.auth(mechanisms)
+		if err != nil {
+			conn.close()
+			return nil, err
+		}
+
+		if err := client.Auth(auth); err != nil {
+			conn.close()
+			return nil, fmt.Errorf("smtp authentication as %s failed: %v", t.config.Username, err)
+		}
+	}
+
+	return conn, nil
+}
+
+// auth picks PLAIN, or LOGIN for servers that only offer that (e.g. Office 365), then CRAM-MD5.
+func (t *Transport) auth(mechanisms string) (smtp.Auth, error) {
+	offered := make(map[string]bool)
+	for _, mechanism := range strings.Fields(strings.ToUpper(mechanisms)) {
+		offered[mechanism] = true
+	}
+
+	switch {
+	case offered["PLAIN"]:
+		return smtp.PlainAuth("", t.config.Username, t.config.Password, t.config.Host), nil
+	case offered["LOGIN"]:
+		return &loginAuth{username: t.config.Username, password: t.config.Password}, nil
+	case offered["CRAM-MD5"]:
+		return smtp.CRAMMD5Auth(t.config.Username, t.config.Password), nil
+	}
+	return nil, fmt.Errorf("smtp server offers no supported authentication mechanism: %s", mechanisms)
+}
+
+// send runs one mail transaction. Recipients are sent one by one so every reply code is kept.
+func (c *smtpConn) send(ctx context.Context, timeout time.Duration, message *Message, data []byte) (*Result, error) {
+	deadline := time.Now().Add(timeout)
+	if timeout <= 0 {
+		deadline = time.Now().Add(time.Minute)
+	}
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	c.raw.SetDeadline(deadline)
+
+	result := &Result{MessageID: message.MessageID}
+
+	// A refused sender is a setup problem like a wrong password, it must not bury the message
+	if err := c.client.Mail(message.From.Address); err != nil {
+		return result, fmt.Errorf("smtp server refused sender %s: %v", message.From.Address, err)
+	}
+
+	var rejected *SMTPError
+	accepted := 0
+	for _, address := range message.Recipients() {
+		code, msg, err := command(c.client.Text, 25, "RCPT TO:<%s>", address)
+		status := RecipientStatus{Address: address, Code: code, Message: msg}
+		result.Recipients = append(result.Recipients, status)
+
+		if err == nil {
+			accepted++
+			continue
+		}
+
+		err = replyError(address, err)
+		if !errors.As(err, &rejected) {
+			return result, err
+		}
+	}
+
+	if accepted == 0 {
+		if rejected == nil {
+			return result, fmt.Errorf("message has no recipient")
+		}
+		return result, rejected
+	}
+
+	if _, _, err := command(c.client.Text, 354, "DATA"); err != nil {
+		return result, replyError("", err)
+	}
+
+	w := c.client.Text.DotWriter()
+	if _, err := w.Write(data); err != nil {
+		return result, fmt.Errorf("failed to write message data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return result, fmt.Errorf("failed to write message data: %w", err)
+	}
+
+	code, msg, err := c.client.Text.ReadResponse(250)
+	result.Code, result.Response = code, msg
+	if err != nil {
+		return result, replyError("", err)
+	}
+
+	return result, nil
+}
+
+func (c *smtpConn) quit() {
+	c.raw.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := c.client.Quit(); err != nil {
+		c.raw.Close()
+	}
+}
+
+func (c *smtpConn) close() {
+	c.client.Close()
+}
+
+// command sends a command and reads its reply, the reply is returned even when it is negative.
+func command(text *textproto.Conn, expectCode int, format string, args ...any) (int, string, error) {
+	id, err := text.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	text.StartResponse(id)
+	defer text.EndResponse(id)
+	return text.ReadResponse(expectCode)
+}
+
+// replyError turns a negative reply into an SMTPError, network errors are returned as they are.
+func replyError(address string, err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return &SMTPError{Address: address, Code: protoErr.Code, Message: protoErr.Msg}
+	}
+	return err
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp does not provide. Like smtp.PlainAuth
+// it refuses to send the password over an unencrypted connection to another host.
+type loginAuth struct {
+	username, password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && server.Name != "localhost" && server.Name != "127.0.0.1" && server.Name != "::1" {
+		return "", nil, fmt.Errorf("unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.Contains(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.Contains(prompt, "password"):
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected LOGIN challenge: %s", fromServer)
+}
@@ -0,0 +1,224 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStub is a minimal SMTP server: it accepts every command except the senders and recipients
+// given a reply of their own, and offers STARTTLS only when asked to (without ever upgrading).
+type smtpStub struct {
+	listener net.Listener
+	mail     string
+	rcpt     map[string]string
+	starttls bool
+}
+
+func startSMTPStub(t *testing.T, stub *smtpStub) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	stub.listener = listener
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	reply("220 stub ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch {
+		case verb == "EHLO":
+			if s.starttls {
+				reply("250-stub")
+				reply("250 STARTTLS")
+			} else {
+				reply("250 stub")
+			}
+		case verb == "MAIL" && s.mail != "":
+			reply(s.mail)
+		case verb == "RCPT":
+			address := line[strings.Index(line, "<")+1 : strings.LastIndex(line, ">")]
+			if code, ok := s.rcpt[address]; ok {
+				reply(code)
+			} else {
+				reply("250 2.1.5 Ok")
+			}
+		case verb == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			for {
+				data, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if data == ".\r\n" {
+					break
+				}
+			}
+			reply("250 2.0.0 Ok: queued as STUB1")
+		case verb == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 Ok")
+		}
+	}
+}
+
+func stubTransport(address, tlsMode string) *Transport {
+	host, port, _ := net.SplitHostPort(address)
+	return NewTransport(Config{Host: host, Port: port, TLS: tlsMode, DialTimeout: 2 * time.Second, SendTimeout: 2 * time.Second})
+}
+
+func stubMessage(to ...string) *Message {
+	message := &Message{
+		From:    mail.Address{Address: "school@example.com"},
+		Subject: "Test",
+		Text:    "Hello",
+	}
+	for _, address := range to {
+		message.To = append(message.To, mail.Address{Address: address})
+	}
+	return message
+}
+
+type permanent interface {
+	Permanent() bool
+}
+
+func TestTransportRecipientReplies(t *testing.T) {
+	tests := []struct {
+		name          string
+		rcpt          map[string]string
+		to            []string
+		wantErr       bool
+		wantPermanent bool
+		wantCodes     []int
+	}{
+		{
+			name:      "accepted",
+			to:        []string{"budi@example.com"},
+			wantCodes: []int{250},
+		},
+		{
+			name:          "mailbox unknown is permanent",
+			rcpt:          map[string]string{"nobody@example.com": "550 5.1.1 User unknown"},
+			to:            []string{"nobody@example.com"},
+			wantErr:       true,
+			wantPermanent: true,
+			wantCodes:     []int{550},
+		},
+		{
+			name:      "mailbox busy is retryable",
+			rcpt:      map[string]string{"busy@example.com": "450 4.2.1 Try again later"},
+			to:        []string{"busy@example.com"},
+			wantErr:   true,
+			wantCodes: []int{450},
+		},
+		{
+			name:      "partial rejection is reported per recipient",
+			rcpt:      map[string]string{"nobody@example.com": "550 5.1.1 User unknown"},
+			to:        []string{"budi@example.com", "nobody@example.com"},
+			wantCodes: []int{250, 550},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := startSMTPStub(t, &smtpStub{rcpt: tt.rcpt})
+			transport := stubTransport(address, TLSNone)
+			defer transport.Close()
+
+			result, err := transport.Send(context.Background(), stubMessage(tt.to...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var smtpErr *SMTPError
+				if !errors.As(err, &smtpErr) {
+					t.Fatalf("error %v is not an SMTPError", err)
+				}
+				if smtpErr.Permanent() != tt.wantPermanent {
+					t.Errorf("Permanent() = %v, want %v", smtpErr.Permanent(), tt.wantPermanent)
+				}
+			}
+
+			if result == nil || len(result.Recipients) != len(tt.wantCodes) {
+				t.Fatalf("result = %+v, want %d recipient replies", result, len(tt.wantCodes))
+			}
+			for i, code := range tt.wantCodes {
+				if result.Recipients[i].Code != code {
+					t.Errorf("recipient %s code = %d, want %d", result.Recipients[i].Address, result.Recipients[i].Code, code)
+				}
+			}
+			if !tt.wantErr && result.Code != 250 {
+				t.Errorf("data reply code = %d, want 250", result.Code)
+			}
+		})
+	}
+}
+
+func TestTransportSetupErrorsStayRetryable(t *testing.T) {
+	tests := []struct {
+		name    string
+		stub    *smtpStub
+		tlsMode string
+		closed  bool
+	}{
+		{name: "connection refused", stub: &smtpStub{}, tlsMode: TLSNone, closed: true},
+		{name: "no STARTTLS offered", stub: &smtpStub{}, tlsMode: TLSStartTLS},
+		{name: "sender refused", stub: &smtpStub{mail: "553 5.7.1 Sender not allowed"}, tlsMode: TLSNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := startSMTPStub(t, tt.stub)
+			if tt.closed {
+				tt.stub.listener.Close()
+			}
+			transport := stubTransport(address, tt.tlsMode)
+			defer transport.Close()
+
+			_, err := transport.Send(context.Background(), stubMessage("budi@example.com"))
+			if err == nil {
+				t.Fatalf("Send succeeded, want a setup error")
+			}
+
+			var failure permanent
+			if errors.As(err, &failure) && failure.Permanent() {
+				t.Errorf("setup error %v is permanent, it must stay retryable", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package delivery
+
+import (
+	"notification/config"
+	"notification/domain"
+	"notification/middleware"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type attendanceHandler struct {
+	uc domain.AttendanceUseCase
+}
+
+func NewAttendanceDeliveryDeploy(app *fiber.App, uc domain.AttendanceUseCase) {
+	handler := &attendanceHandler{
+		uc: uc,
+	}
+
+	route := app.Group("/attendance")
+	route.Post("/roll-call", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.SubmitRollCall)
+	route.Get("/all", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.GetAttendances)
+}
+
+func (h *attendanceHandler) SubmitRollCall(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	var req domain.RollCall
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "SubmitRollCall")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if _, err := govalidator.ValidateStruct(&req); err != nil {
+		var validatorResponse []string
+		validationErrors := govalidator.ErrorsByField(err)
+		for i := range validationErrors {
+			validatorResponse = append(validatorResponse, validationErrors[i])
+		}
+
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "SubmitRollCall")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	data, err := h.uc.SubmitRollCall(c.Context(), &req, userToken.UserID)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "SubmitRollCall")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to submit roll call",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusCreated, "SubmitRollCall")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Roll call submitted successfully",
+		"data":    data,
+	})
+}
+
+func (h *attendanceHandler) GetAttendances(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetAttendances(c.Context(), c.Query("date"), c.Query("subject_code"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetAttendances")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get attendances",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetAttendances")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Attendances retrieved successfully",
+		"data":    data,
+	})
+}
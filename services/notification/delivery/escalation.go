@@ -0,0 +1,203 @@
+package delivery
+
+import (
+	"notification/config"
+	"notification/domain"
+	"notification/middleware"
+	"strconv"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type escalationRuleHandler struct {
+	uc domain.EscalationRuleUseCase
+}
+
+func NewEscalationRuleDeliveryDeploy(app *fiber.App, uc domain.EscalationRuleUseCase) {
+	handler := &escalationRuleHandler{
+		uc: uc,
+	}
+
+	route := app.Group("/escalation")
+	route.Get("/rule/all", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.GetAllEscalationRules)
+	route.Post("/rule/create", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.CreateEscalationRule)
+	route.Put("/rule/modify/:escalation_rule_id", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.UpdateEscalationRule)
+	route.Delete("/rule/rm/:escalation_rule_id", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.DeleteEscalationRule)
+	route.Get("/alert/all", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.GetEscalationAlerts)
+}
+
+func (h *escalationRuleHandler) GetAllEscalationRules(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetAllEscalationRules(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetAllEscalationRules")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get escalation rules",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetAllEscalationRules")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Escalation rules retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *escalationRuleHandler) CreateEscalationRule(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	var req domain.EscalationRule
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateEscalationRule")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if validatorResponse := validateEscalationRule(&req); validatorResponse != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateEscalationRule")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	data, err := h.uc.CreateEscalationRule(c.Context(), &req)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "CreateEscalationRule")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to create escalation rule",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusCreated, "CreateEscalationRule")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Escalation rule created successfully",
+		"data":    data,
+	})
+}
+
+func (h *escalationRuleHandler) UpdateEscalationRule(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("escalation_rule_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateEscalationRule")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on escalation_rule_id",
+		})
+	}
+
+	var req domain.EscalationRule
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateEscalationRule")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if validatorResponse := validateEscalationRule(&req); validatorResponse != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateEscalationRule")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	err = h.uc.UpdateEscalationRule(c.Context(), id, &req)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "UpdateEscalationRule")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to update escalation rule",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "UpdateEscalationRule")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Escalation rule updated successfully",
+	})
+}
+
+func (h *escalationRuleHandler) DeleteEscalationRule(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("escalation_rule_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "DeleteEscalationRule")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on escalation_rule_id",
+		})
+	}
+
+	err = h.uc.DeleteEscalationRule(c.Context(), id)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "DeleteEscalationRule")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to delete escalation rule",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "DeleteEscalationRule")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Escalation rule deleted successfully",
+	})
+}
+
+func (h *escalationRuleHandler) GetEscalationAlerts(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetEscalationAlerts(c.Context(), c.Query("student_nsn"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetEscalationAlerts")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get escalation alerts",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetEscalationAlerts")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Escalation alerts retrieved successfully",
+		"data":    data,
+	})
+}
+
+func validateEscalationRule(req *domain.EscalationRule) []string {
+	_, err := govalidator.ValidateStruct(req)
+	if err == nil {
+		return nil
+	}
+
+	var validatorResponse []string
+	validationErrors := govalidator.ErrorsByField(err)
+	for i := range validationErrors {
+		validatorResponse = append(validatorResponse, validationErrors[i])
+	}
+	return validatorResponse
+}
@@ -0,0 +1,271 @@
+package delivery
+
+import (
+	"notification/config"
+	"notification/domain"
+	"notification/middleware"
+	"strconv"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type excusedAbsenceHandler struct {
+	uc domain.ExcusedAbsenceUseCase
+}
+
+func NewExcusedAbsenceDeliveryDeploy(app *fiber.App, uc domain.ExcusedAbsenceUseCase) {
+	handler := &excusedAbsenceHandler{
+		uc: uc,
+	}
+
+	route := app.Group("/excused-absence")
+	route.Get("/all", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.GetAllExcusedAbsences)
+	route.Get("/:excused_absence_id", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.GetExcusedAbsenceByID)
+	route.Post("/create", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.CreateExcusedAbsence)
+	route.Put("/modify/:excused_absence_id", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.UpdateExcusedAbsence)
+	route.Put("/review/:excused_absence_id", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.ReviewExcusedAbsence)
+	route.Delete("/rm/:excused_absence_id", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.DeleteExcusedAbsence)
+}
+
+func (h *excusedAbsenceHandler) GetAllExcusedAbsences(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetAllExcusedAbsences(c.Context(), c.Query("student_nsn"), c.Query("status"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetAllExcusedAbsences")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get excused absences",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetAllExcusedAbsences")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Excused absences retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *excusedAbsenceHandler) GetExcusedAbsenceByID(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("excused_absence_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "GetExcusedAbsenceByID")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on excused_absence_id",
+		})
+	}
+
+	data, err := h.uc.GetExcusedAbsenceByID(c.Context(), id)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusNotFound, "GetExcusedAbsenceByID")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get excused absence",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetExcusedAbsenceByID")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Excused absence retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *excusedAbsenceHandler) CreateExcusedAbsence(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	var req domain.ExcusedAbsencePayload
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateExcusedAbsence")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if validatorResponse := validateExcusedAbsencePayload(&req); validatorResponse != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateExcusedAbsence")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	data, err := h.uc.CreateExcusedAbsence(c.Context(), &req, userToken.UserID)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "CreateExcusedAbsence")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to create excused absence",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusCreated, "CreateExcusedAbsence")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Excused absence created successfully",
+		"data":    data,
+	})
+}
+
+func (h *excusedAbsenceHandler) UpdateExcusedAbsence(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("excused_absence_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateExcusedAbsence")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on excused_absence_id",
+		})
+	}
+
+	var req domain.ExcusedAbsencePayload
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateExcusedAbsence")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if validatorResponse := validateExcusedAbsencePayload(&req); validatorResponse != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateExcusedAbsence")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	err = h.uc.UpdateExcusedAbsence(c.Context(), id, &req)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "UpdateExcusedAbsence")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to update excused absence",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "UpdateExcusedAbsence")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Excused absence updated successfully",
+	})
+}
+
+func (h *excusedAbsenceHandler) DeleteExcusedAbsence(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("excused_absence_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "DeleteExcusedAbsence")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on excused_absence_id",
+		})
+	}
+
+	err = h.uc.DeleteExcusedAbsence(c.Context(), id)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "DeleteExcusedAbsence")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to delete excused absence",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "DeleteExcusedAbsence")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Excused absence deleted successfully",
+	})
+}
+
+// ReviewExcusedAbsence approves or rejects an excuse a parent sent through WhatsApp.
+func (h *excusedAbsenceHandler) ReviewExcusedAbsence(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("excused_absence_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "ReviewExcusedAbsence")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on excused_absence_id",
+		})
+	}
+
+	var req domain.ExcuseReviewPayload
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "ReviewExcusedAbsence")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if _, err := govalidator.ValidateStruct(&req); err != nil {
+		var validatorResponse []string
+		validationErrors := govalidator.ErrorsByField(err)
+		for i := range validationErrors {
+			validatorResponse = append(validatorResponse, validationErrors[i])
+		}
+
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "ReviewExcusedAbsence")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	data, err := h.uc.ReviewExcusedAbsence(c.Context(), id, &req, userToken.UserID)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "ReviewExcusedAbsence")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to review excused absence",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "ReviewExcusedAbsence")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Excused absence reviewed successfully",
+		"data":    data,
+	})
+}
+
+func validateExcusedAbsencePayload(req *domain.ExcusedAbsencePayload) []string {
+	_, err := govalidator.ValidateStruct(req)
+	if err == nil {
+		return nil
+	}
+
+	var validatorResponse []string
+	validationErrors := govalidator.ErrorsByField(err)
+	for i := range validationErrors {
+		validatorResponse = append(validatorResponse, validationErrors[i])
+	}
+	return validatorResponse
+}
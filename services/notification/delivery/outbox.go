@@ -0,0 +1,112 @@
+package delivery
+
+import (
+	"fmt"
+	"notification/config"
+	"notification/domain"
+	"notification/middleware"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type outboxHandler struct {
+	uc domain.OutboxUseCase
+}
+
+func NewOutboxDeliveryDeploy(app *fiber.App, uc domain.OutboxUseCase) {
+	handler := &outboxHandler{
+		uc: uc,
+	}
+
+	route := app.Group("/outbox")
+	route.Get("/dead", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.GetDeadMessages)
+	route.Post("/dead/redrive", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.RedriveDeadMessages)
+	route.Post("/dead/:outbox_message_id/redrive", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.RedriveDeadMessage)
+}
+
+func (h *outboxHandler) GetDeadMessages(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetDeadMessages(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetDeadMessages")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get dead messages",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetDeadMessages")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Dead messages retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *outboxHandler) RedriveDeadMessage(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("outbox_message_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "RedriveDeadMessage")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on outbox_message_id",
+		})
+	}
+
+	err = h.uc.RedriveDeadMessage(c.Context(), id)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "RedriveDeadMessage")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to redrive dead message",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "RedriveDeadMessage")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Dead message queued for another attempt",
+	})
+}
+
+func (h *outboxHandler) RedriveDeadMessages(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	var payload struct {
+		JobID string `json:"job_id"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&payload); err != nil {
+			config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "RedriveDeadMessages")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	count, err := h.uc.RedriveDeadMessages(c.Context(), payload.JobID)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "RedriveDeadMessages")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to redrive dead messages",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "RedriveDeadMessages")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("%d dead messages queued for another attempt", count),
+	})
+}
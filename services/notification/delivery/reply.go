@@ -0,0 +1,115 @@
+package delivery
+
+import (
+	"fmt"
+	"notification/config"
+	"notification/domain"
+	"notification/middleware"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type parentReplyHandler struct {
+	uc domain.ParentReplyUseCase
+}
+
+func NewParentReplyDeliveryDeploy(app *fiber.App, uc domain.ParentReplyUseCase) {
+	handler := &parentReplyHandler{
+		uc: uc,
+	}
+
+	route := app.Group("/parent-reply")
+	route.Get("/all", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.GetAllParentReplies)
+	route.Get("/media/:parent_reply_id", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.GetParentReplyMedia)
+	route.Put("/read/:parent_reply_id", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.MarkParentReplyRead)
+}
+
+// GetAllParentReplies lists the WhatsApp replies of parents, newest first. ?unread=true only returns the unread ones.
+func (h *parentReplyHandler) GetAllParentReplies(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetAllParentReplies(c.Context(), c.QueryBool("unread"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetAllParentReplies")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get parent replies",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetAllParentReplies")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Parent replies retrieved successfully",
+		"data":    data,
+	})
+}
+
+// GetParentReplyMedia serves the photo or document attached to a reply as it was received.
+func (h *parentReplyHandler) GetParentReplyMedia(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("parent_reply_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "GetParentReplyMedia")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on parent_reply_id",
+		})
+	}
+
+	reply, err := h.uc.GetParentReplyMedia(c.Context(), id)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusNotFound, "GetParentReplyMedia")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get parent reply media",
+		})
+	}
+
+	contentType := "application/octet-stream"
+	if reply.MediaMimeType != nil {
+		contentType = *reply.MediaMimeType
+	}
+	c.Set("Content-Type", contentType)
+	if reply.MediaFileName != nil {
+		c.Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", *reply.MediaFileName))
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetParentReplyMedia")
+	return c.Status(fiber.StatusOK).Send(reply.Media)
+}
+
+func (h *parentReplyHandler) MarkParentReplyRead(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("parent_reply_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "MarkParentReplyRead")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on parent_reply_id",
+		})
+	}
+
+	err = h.uc.MarkParentReplyRead(c.Context(), id, userToken.UserID)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "MarkParentReplyRead")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to mark parent reply as read",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "MarkParentReplyRead")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Parent reply marked as read",
+	})
+}
@@ -0,0 +1,123 @@
+package delivery
+
+import (
+	"notification/config"
+	"notification/domain"
+	"notification/middleware"
+	"strconv"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type scheduledNotificationHandler struct {
+	uc domain.ScheduledNotificationUseCase
+}
+
+func NewScheduledNotificationDeliveryDeploy(app *fiber.App, uc domain.ScheduledNotificationUseCase) {
+	handler := &scheduledNotificationHandler{
+		uc: uc,
+	}
+
+	route := app.Group("/scheduled-notification")
+	route.Get("/all", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.GetAllScheduledNotifications)
+	route.Post("/create", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.CreateScheduledNotification)
+	route.Put("/cancel/:scheduled_notification_id", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.CancelScheduledNotification)
+}
+
+func (h *scheduledNotificationHandler) GetAllScheduledNotifications(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetAllScheduledNotifications(c.Context(), c.Query("status"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetAllScheduledNotifications")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get scheduled notifications",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetAllScheduledNotifications")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Scheduled notifications retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *scheduledNotificationHandler) CreateScheduledNotification(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	var req domain.ScheduledNotificationPayload
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateScheduledNotification")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if _, err := govalidator.ValidateStruct(&req); err != nil {
+		var validatorResponse []string
+		validationErrors := govalidator.ErrorsByField(err)
+		for i := range validationErrors {
+			validatorResponse = append(validatorResponse, validationErrors[i])
+		}
+
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateScheduledNotification")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	data, err := h.uc.CreateScheduledNotification(c.Context(), &req, userToken.UserID)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "CreateScheduledNotification")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to schedule notification",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusCreated, "CreateScheduledNotification")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Notification scheduled successfully",
+		"data":    data,
+	})
+}
+
+func (h *scheduledNotificationHandler) CancelScheduledNotification(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("scheduled_notification_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CancelScheduledNotification")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on scheduled_notification_id",
+		})
+	}
+
+	err = h.uc.CancelScheduledNotification(c.Context(), id)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "CancelScheduledNotification")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to cancel scheduled notification",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "CancelScheduledNotification")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Scheduled notification cancelled successfully",
+	})
+}
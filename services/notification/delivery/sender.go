@@ -1,11 +1,13 @@
 package delivery
 
 import (
+	"errors"
 	"notification/config"
 	"notification/domain"
 	"notification/middleware"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 type senderHandler struct {
@@ -20,13 +22,46 @@ func NewSenderDeliveryDeploy(app *fiber.App, uc domain.SenderUseCase) {
 	route := app.Group("/sender")
 	route.Post("/send-mass", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.sendMassHandler)
 	route.Post("/send-mass/exam-result", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.SendTestScores)
+	route.Post("/preview", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.PreviewHandler)
+	route.Get("/jobs/:id", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.GetJobStatus)
+}
+
+func (h *senderHandler) GetJobStatus(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.suc.GetJobStatus(c.Context(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			config.PrintLogInfo(&userToken.Username, fiber.StatusNotFound, "GetJobStatus")
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+				"message": "Notification job not found",
+			})
+		}
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetJobStatus")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get job status",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetJobStatus")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Job status retrieved successfully",
+		"data":    data,
+	})
 }
 
 func (h *senderHandler) SendTestScores(c *fiber.Ctx) error {
 	userToken := c.Locals("user").(*domain.Claims)
 
+	// report_card attaches a PDF report card to every message
 	var payload struct {
-		ExamType string `json:"exam_type"`
+		ExamType   string `json:"exam_type"`
+		ReportCard bool   `json:"report_card"`
 	}
 
 	err := c.BodyParser(&payload)
@@ -39,7 +74,12 @@ func (h *senderHandler) SendTestScores(c *fiber.Ctx) error {
 		}))
 	}
 
-	err = h.suc.SendTestScores(c.Context(), payload.ExamType)
+	var idempotencyKey *string
+	if key := c.Get("Idempotency-Key"); key != "" {
+		idempotencyKey = &key
+	}
+
+	job, err := h.suc.SendTestScores(c.Context(), payload.ExamType, payload.ReportCard, &userToken.UserID, idempotencyKey)
 	if err != nil {
 		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "SendTestScores")
 		return c.Status(fiber.StatusInternalServerError).JSON((fiber.Map{
@@ -49,10 +89,14 @@ func (h *senderHandler) SendTestScores(c *fiber.Ctx) error {
 		}))
 	}
 
-	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "SendTestScores")
-	return c.Status(fiber.StatusOK).JSON((fiber.Map{
+	config.PrintLogInfo(&userToken.Username, fiber.StatusAccepted, "SendTestScores")
+	return c.Status(fiber.StatusAccepted).JSON((fiber.Map{
 		"success": true,
-		"message": "Successfully announce test scores",
+		"message": "Test scores announcement queued",
+		"data": fiber.Map{
+			"job_id":  job.JobID,
+			"skipped": job.Skipped,
+		},
 	}))
 }
 
@@ -74,7 +118,14 @@ func (h *senderHandler) sendMassHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.suc.SendMass(c.Context(), &payload.NSNList, &userID, payload.SubjectCode); err != nil {
+	// Clients may retry with the same Idempotency-Key header without notifying parents twice
+	var idempotencyKey *string
+	if key := c.Get("Idempotency-Key"); key != "" {
+		idempotencyKey = &key
+	}
+
+	job, err := h.suc.SendMass(c.Context(), &payload.NSNList, &userID, payload.SubjectCode, nil, idempotencyKey)
+	if err != nil {
 		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "sendMassHandler")
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -84,10 +135,76 @@ func (h *senderHandler) sendMassHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "sendMassHandler")
+	config.PrintLogInfo(&userToken.Username, fiber.StatusAccepted, "sendMassHandler")
+
+	// In digest mode the absences are only recorded, the daily digest notifies the parents
+	if job == nil {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "absences recorded for the daily digest",
+			"success": true,
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "notifications queued",
+		"success": true,
+		"data": fiber.Map{
+			"job_id":     job.JobID,
+			"skipped":    job.Skipped,
+			"duplicates": job.Duplicates,
+		},
+	})
+}
+
+// PreviewHandler accepts the payload of either send-mass or exam-result and returns what would be sent,
+// an exam_type selects the exam result preview.
+func (h *senderHandler) PreviewHandler(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	var payload struct {
+		NSNList     []string `json:"nsn_list"`
+		SubjectCode string   `json:"subject_code"`
+		ExamType    string   `json:"exam_type"`
+	}
+
+	if err := c.BodyParser(&payload); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "PreviewHandler")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	var data *domain.NotificationPreviewResult
+	var err error
+	switch {
+	case payload.ExamType != "":
+		data, err = h.suc.PreviewTestScores(c.Context(), payload.ExamType)
+	case len(payload.NSNList) > 0 && payload.SubjectCode != "":
+		data, err = h.suc.PreviewMass(c.Context(), &payload.NSNList, payload.SubjectCode)
+	default:
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "PreviewHandler")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "either exam_type or nsn_list and subject_code are required",
+			"message": "Invalid request body",
+		})
+	}
+
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "PreviewHandler")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to preview notifications",
+		})
+	}
 
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "PreviewHandler")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "notifications sent successfully",
 		"success": true,
+		"message": "Notifications preview rendered successfully",
+		"data":    data,
 	})
 }
@@ -0,0 +1,192 @@
+package delivery
+
+import (
+	"notification/config"
+	"notification/domain"
+	"notification/middleware"
+	"strconv"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type schoolSettingHandler struct {
+	uc domain.SchoolSettingUseCase
+}
+
+func NewSchoolSettingDeliveryDeploy(app *fiber.App, uc domain.SchoolSettingUseCase) {
+	handler := &schoolSettingHandler{
+		uc: uc,
+	}
+
+	route := app.Group("/school-setting")
+	route.Get("/", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.GetSchoolSetting)
+	route.Put("/modify", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.UpdateSchoolSetting)
+	route.Get("/holiday/all", middleware.AuthRequired(), middleware.RoleRequired("admin", "staff"), handler.GetAllSchoolHolidays)
+	route.Post("/holiday/create", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.CreateSchoolHoliday)
+	route.Delete("/holiday/rm/:school_holiday_id", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.DeleteSchoolHoliday)
+}
+
+func (h *schoolSettingHandler) GetSchoolSetting(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetSchoolSetting(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetSchoolSetting")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get school setting",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetSchoolSetting")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "School setting retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *schoolSettingHandler) UpdateSchoolSetting(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	var req domain.SchoolSettingPayload
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateSchoolSetting")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if _, err := govalidator.ValidateStruct(&req); err != nil {
+		var validatorResponse []string
+		validationErrors := govalidator.ErrorsByField(err)
+		for i := range validationErrors {
+			validatorResponse = append(validatorResponse, validationErrors[i])
+		}
+
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateSchoolSetting")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	data, err := h.uc.UpdateSchoolSetting(c.Context(), &req)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "UpdateSchoolSetting")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to update school setting",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "UpdateSchoolSetting")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "School setting updated successfully",
+		"data":    data,
+	})
+}
+
+func (h *schoolSettingHandler) GetAllSchoolHolidays(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetAllSchoolHolidays(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetAllSchoolHolidays")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get school holidays",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetAllSchoolHolidays")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "School holidays retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *schoolSettingHandler) CreateSchoolHoliday(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	var req domain.SchoolHolidayPayload
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateSchoolHoliday")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if _, err := govalidator.ValidateStruct(&req); err != nil {
+		var validatorResponse []string
+		validationErrors := govalidator.ErrorsByField(err)
+		for i := range validationErrors {
+			validatorResponse = append(validatorResponse, validationErrors[i])
+		}
+
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateSchoolHoliday")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	data, err := h.uc.CreateSchoolHoliday(c.Context(), &req)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "CreateSchoolHoliday")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to create school holiday",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusCreated, "CreateSchoolHoliday")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "School holiday created successfully",
+		"data":    data,
+	})
+}
+
+func (h *schoolSettingHandler) DeleteSchoolHoliday(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("school_holiday_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "DeleteSchoolHoliday")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on school_holiday_id",
+		})
+	}
+
+	err = h.uc.DeleteSchoolHoliday(c.Context(), id)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "DeleteSchoolHoliday")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to delete school holiday",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "DeleteSchoolHoliday")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "School holiday deleted successfully",
+	})
+}
@@ -43,6 +43,7 @@ func NewStudentParentHandlerDeploy(app *fiber.App, useCase domain.StudentParentU
 	route.Get("/download-template", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.DownloadTemplate)
 	route.Delete("/review/dcr/:request_id", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.DeleteDCR)
 	route.Post("/approve/dcr", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.ApproveDCR)
+	route.Put("/parent/:parent_id/preferences", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.UpdateParentPreferences)
 }
 
 func (sph *studentParentHandler) ApproveDCR(c *fiber.Ctx) error {
@@ -420,6 +421,11 @@ func (sph *studentParentHandler) processCSVFile(c context.Context, filePath stri
 
 		trimmedEmail := strings.TrimSpace(row[9])
 		row[9] = trimmedEmail
+
+		// parent_language is an optional 11th column, older templates stop at parent_email
+		if len(row) > 10 {
+			row[10] = strings.ToLower(strings.TrimSpace(row[10]))
+		}
 		parentErrors := validateParent(row[6:], i+2, emailRegex)
 		if len(parentErrors) > 0 {
 			errList = append(errList, parentErrors...)
@@ -447,6 +453,9 @@ func (sph *studentParentHandler) processCSVFile(c context.Context, filePath stri
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
+			if len(row) > 10 {
+				parent.PreferredLanguage = getStringPointer(row[10])
+			}
 
 			listStudentAndParent = append(listStudentAndParent, domain.StudentAndParent{
 				Student: student,
@@ -565,6 +574,11 @@ func validateParent(row []string, rowNum int, emailRegex *regexp.Regexp) []strin
 		}
 	}
 
+	// Validate Preferred Language (optional)
+	if len(row) > 4 && row[4] != "" && !domain.IsSupportedLanguage(row[4]) {
+		errList = append(errList, fmt.Sprintf("row %d: Parent language: %s, must be 'ind' or 'eng'", rowNum, row[4]))
+	}
+
 	return errList
 }
 
@@ -776,7 +790,8 @@ func ValidateDataChangeRequest(data *domain.ParentDataChangeRequest) error {
 	if (data.NewParentName == nil || *data.NewParentName == "") &&
 		(data.NewParentTelephone == nil || *data.NewParentTelephone == "") &&
 		(data.NewParentEmail == nil || *data.NewParentEmail == "") &&
-		(data.NewParentGender == nil || *data.NewParentGender == "") {
+		(data.NewParentGender == nil || *data.NewParentGender == "") &&
+		(data.NewParentLanguage == nil || *data.NewParentLanguage == "") {
 		return errors.New("please input at least one new data field")
 	}
 
@@ -831,3 +846,44 @@ func (sph *studentParentHandler) DataChangeRequest(c *fiber.Ctx) error {
 		"message": "Successfully sent data changes request",
 	})
 }
+
+func (sph *studentParentHandler) UpdateParentPreferences(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	parentID, err := strconv.Atoi(c.Params("parent_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateParentPreferences")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on parent_id",
+		})
+	}
+
+	var prefs domain.ParentNotificationPreferences
+	if err := c.BodyParser(&prefs); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateParentPreferences")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	parent, err := sph.uc.UpdateParentPreferences(c.Context(), parentID, &prefs)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "UpdateParentPreferences")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to update notification preferences",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "UpdateParentPreferences")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Notification preferences updated successfully",
+		"data":    parent,
+	})
+}
@@ -0,0 +1,205 @@
+package delivery
+
+import (
+	"notification/config"
+	"notification/domain"
+	"notification/middleware"
+	"strconv"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gofiber/fiber/v2"
+)
+
+type messageTemplateHandler struct {
+	uc domain.MessageTemplateUseCase
+}
+
+func NewMessageTemplateDeliveryDeploy(app *fiber.App, uc domain.MessageTemplateUseCase) {
+	handler := &messageTemplateHandler{
+		uc: uc,
+	}
+
+	route := app.Group("/template")
+	route.Get("/all", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.GetAllTemplates)
+	route.Get("/:template_id", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.GetTemplateByID)
+	route.Post("/create", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.CreateTemplate)
+	route.Put("/modify/:template_id", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.UpdateTemplate)
+	route.Delete("/rm/:template_id", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.DeleteTemplate)
+}
+
+func (h *messageTemplateHandler) GetAllTemplates(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetAllTemplates(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetAllTemplates")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get message templates",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetAllTemplates")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Message templates retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *messageTemplateHandler) GetTemplateByID(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("template_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "GetTemplateByID")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on template_id",
+		})
+	}
+
+	data, err := h.uc.GetTemplateByID(c.Context(), id)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusNotFound, "GetTemplateByID")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get message template",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetTemplateByID")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Message template retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *messageTemplateHandler) CreateTemplate(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	var req domain.MessageTemplate
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateTemplate")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if _, err := govalidator.ValidateStruct(&req); err != nil {
+		var validatorResponse []string
+		validationErrors := govalidator.ErrorsByField(err)
+		for i := range validationErrors {
+			validatorResponse = append(validatorResponse, validationErrors[i])
+		}
+
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "CreateTemplate")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   validatorResponse,
+			"message": "Invalid request body",
+		})
+	}
+
+	err := h.uc.CreateTemplate(c.Context(), &req)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "CreateTemplate")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to create message template",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusCreated, "CreateTemplate")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Message template created successfully",
+		"data":    req,
+	})
+}
+
+func (h *messageTemplateHandler) UpdateTemplate(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("template_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateTemplate")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on template_id",
+		})
+	}
+
+	var req domain.MessageTemplate
+	if err := c.BodyParser(&req); err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateTemplate")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Body == "" {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "UpdateTemplate")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Body is required",
+			"message": "Invalid request body",
+		})
+	}
+
+	err = h.uc.UpdateTemplate(c.Context(), id, &req)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "UpdateTemplate")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to update message template",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "UpdateTemplate")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Message template updated successfully",
+	})
+}
+
+func (h *messageTemplateHandler) DeleteTemplate(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	id, err := strconv.Atoi(c.Params("template_id"))
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusBadRequest, "DeleteTemplate")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Converter failure on template_id",
+		})
+	}
+
+	err = h.uc.DeleteTemplate(c.Context(), id)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "DeleteTemplate")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to delete message template",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "DeleteTemplate")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Message template deleted successfully",
+	})
+}
@@ -0,0 +1,186 @@
+package delivery
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"notification/config"
+	"notification/domain"
+	"notification/middleware"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type whatsappSessionHandler struct {
+	uc domain.WhatsAppSessionUseCase
+}
+
+func NewWhatsAppSessionDeliveryDeploy(app *fiber.App, uc domain.WhatsAppSessionUseCase) {
+	handler := &whatsappSessionHandler{
+		uc: uc,
+	}
+
+	route := app.Group("/whatsapp")
+	route.Get("/status", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.GetStatus)
+	route.Get("/qr", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.GetQR)
+	route.Get("/qr.png", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.GetQRImage)
+	route.Get("/events", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.StreamEvents)
+	route.Post("/pair", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.Pair)
+	route.Post("/logout", middleware.AuthRequired(), middleware.RoleRequired("admin"), handler.Logout)
+}
+
+func (h *whatsappSessionHandler) GetStatus(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetStatus(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetStatus")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get whatsapp status",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetStatus")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Whatsapp status retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *whatsappSessionHandler) GetQR(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetQR(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusNotFound, "GetQR")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get whatsapp QR code",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetQR")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Whatsapp QR code retrieved successfully",
+		"data":    data,
+	})
+}
+
+func (h *whatsappSessionHandler) GetQRImage(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	data, err := h.uc.GetQR(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusNotFound, "GetQRImage")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get whatsapp QR code",
+		})
+	}
+
+	png, err := base64.StdEncoding.DecodeString(data.PNG)
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "GetQRImage")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to get whatsapp QR code",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "GetQRImage")
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).Send(png)
+}
+
+// StreamEvents streams the session events (new QR codes, pairing and connection changes) as server-sent events.
+func (h *whatsappSessionHandler) StreamEvents(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	events, unsubscribe := h.uc.Subscribe()
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "StreamEvents")
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		// The heartbeat notices a client that went away while no event is published
+		heartbeat := time.NewTicker(15 * time.Second)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case evt, ok := <-events:
+				if !ok {
+					return
+				}
+
+				payload, err := json.Marshal(evt)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Event, payload)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func (h *whatsappSessionHandler) Pair(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	err := h.uc.Pair(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusConflict, "Pair")
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to start whatsapp pairing",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusAccepted, "Pair")
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "Whatsapp pairing started, fetch the QR code to link the device",
+	})
+}
+
+func (h *whatsappSessionHandler) Logout(c *fiber.Ctx) error {
+	userToken := c.Locals("user").(*domain.Claims)
+
+	err := h.uc.Logout(c.Context())
+	if err != nil {
+		config.PrintLogInfo(&userToken.Username, fiber.StatusInternalServerError, "Logout")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"message": "Failed to log out of whatsapp",
+		})
+	}
+
+	config.PrintLogInfo(&userToken.Username, fiber.StatusOK, "Logout")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Whatsapp logged out successfully",
+	})
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"notification/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type attendanceRepository struct {
+	db *gorm.DB
+}
+
+func NewAttendanceRepository(db *gorm.DB) domain.AttendanceRepo {
+	return &attendanceRepository{
+		db: db,
+	}
+}
+
+// recordRollCall stores the register of one session, submitting the same session again overwrites the statuses.
+func recordRollCall(tx *gorm.DB, rollCall *domain.RollCall, userID int) ([]domain.Attendance, error) {
+	date, err := time.Parse("2006-01-02", rollCall.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %s, expected format YYYY-MM-DD", rollCall.Date)
+	}
+
+	var subjectCount int64
+	err = tx.Model(&domain.Subject{}).Where("subject_code = ?", rollCall.SubjectCode).Count(&subjectCount).Error
+	if err != nil {
+		return nil, fmt.Errorf("error checking subject: %w", err)
+	}
+	if subjectCount == 0 {
+		return nil, fmt.Errorf("subject with code %s not found", rollCall.SubjectCode)
+	}
+
+	nsnList := make([]string, 0, len(rollCall.Entries))
+	seen := make(map[string]bool, len(rollCall.Entries))
+	for _, entry := range rollCall.Entries {
+		if seen[entry.StudentNSN] {
+			return nil, fmt.Errorf("student %s is listed more than once", entry.StudentNSN)
+		}
+		seen[entry.StudentNSN] = true
+		nsnList = append(nsnList, entry.StudentNSN)
+	}
+
+	var students []domain.Student
+	err = tx.Where("student_nsn IN (?)", nsnList).Find(&students).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch students: %w", err)
+	}
+
+	known := make(map[string]bool, len(students))
+	for _, student := range students {
+		known[student.StudentNSN] = true
+	}
+
+	records := make([]domain.Attendance, 0, len(rollCall.Entries))
+	for _, entry := range rollCall.Entries {
+		if !known[entry.StudentNSN] {
+			return nil, fmt.Errorf("student with StudentNSN %s not found", entry.StudentNSN)
+		}
+
+		records = append(records, domain.Attendance{
+			StudentNSN:  entry.StudentNSN,
+			SubjectCode: rollCall.SubjectCode,
+			Date:        date,
+			Session:     rollCall.Session,
+			Status:      entry.Status,
+			UserID:      userID,
+		})
+	}
+
+	if err := upsertAttendances(tx, records); err != nil {
+		return nil, err
+	}
+
+	var attendances []domain.Attendance
+	err = tx.Preload("Student").Preload("Subject").
+		Where("student_nsn IN (?) AND subject_code = ? AND date = ? AND session = ?", nsnList, rollCall.SubjectCode, rollCall.Date, rollCall.Session).
+		Order("student_nsn").
+		Find(&attendances).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to save roll call: %w", err)
+	}
+
+	return attendances, nil
+}
+
+func (r *attendanceRepository) GetAttendances(ctx context.Context, date, subjectCode string) (*[]domain.Attendance, error) {
+	var attendances []domain.Attendance
+
+	query := r.db.WithContext(ctx).Preload("Student").Preload("Subject")
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("invalid date %s, expected format YYYY-MM-DD", date)
+		}
+		query = query.Where("date = ?", date)
+	}
+	if subjectCode != "" {
+		query = query.Where("subject_code = ?", subjectCode)
+	}
+
+	err := query.Order("date DESC, session, subject_code, student_nsn").Find(&attendances).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get attendances: %w", err)
+	}
+
+	return &attendances, nil
+}
+
+// upsertAttendances inserts the records or, for a session already on record, overwrites its status.
+func upsertAttendances(tx *gorm.DB, records []domain.Attendance) error {
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "student_nsn"}, {Name: "subject_code"}, {Name: "date"}, {Name: "session"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "user_id", "updated_at"}),
+	}).Create(&records).Error
+	if err != nil {
+		return fmt.Errorf("failed to record attendance: %w", err)
+	}
+	return nil
+}
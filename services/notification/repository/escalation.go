@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"notification/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type escalationRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewEscalationRuleRepository(db *gorm.DB) domain.EscalationRuleRepo {
+	return &escalationRuleRepository{
+		db: db,
+	}
+}
+
+func (r *escalationRuleRepository) GetAllEscalationRules(ctx context.Context) (*[]domain.EscalationRule, error) {
+	var rules []domain.EscalationRule
+	err := r.db.WithContext(ctx).Order("escalation_rule_id").Find(&rules).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get escalation rules: %w", err)
+	}
+	return &rules, nil
+}
+
+func (r *escalationRuleRepository) CreateEscalationRule(ctx context.Context, rule *domain.EscalationRule) (*domain.EscalationRule, error) {
+	if err := checkEscalationRule(rule); err != nil {
+		return nil, err
+	}
+
+	newRule := domain.EscalationRule{
+		Name:        rule.Name,
+		Period:      rule.Period,
+		Unit:        rule.Unit,
+		Threshold:   rule.Threshold,
+		MinSessions: rule.MinSessions,
+		Active:      rule.Active,
+	}
+
+	if err := r.db.WithContext(ctx).Create(&newRule).Error; err != nil {
+		return nil, fmt.Errorf("could not create escalation rule: %w", err)
+	}
+
+	return &newRule, nil
+}
+
+func (r *escalationRuleRepository) UpdateEscalationRule(ctx context.Context, escalationRuleID int, rule *domain.EscalationRule) error {
+	if err := checkEscalationRule(rule); err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"name":         rule.Name,
+		"period":       rule.Period,
+		"unit":         rule.Unit,
+		"threshold":    rule.Threshold,
+		"min_sessions": rule.MinSessions,
+		"updated_at":   time.Now(),
+	}
+	if rule.Active != nil {
+		fields["active"] = *rule.Active
+	}
+
+	result := r.db.WithContext(ctx).Model(&domain.EscalationRule{}).
+		Where("escalation_rule_id = ?", escalationRuleID).
+		Updates(fields)
+	if result.Error != nil {
+		return fmt.Errorf("could not update escalation rule: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("escalation rule with ID %d not found", escalationRuleID)
+	}
+
+	return nil
+}
+
+func (r *escalationRuleRepository) DeleteEscalationRule(ctx context.Context, escalationRuleID int) error {
+	result := r.db.WithContext(ctx).Where("escalation_rule_id = ?", escalationRuleID).Delete(&domain.EscalationRule{})
+	if result.Error != nil {
+		return fmt.Errorf("could not delete escalation rule: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("escalation rule with ID %d not found", escalationRuleID)
+	}
+
+	return nil
+}
+
+func (r *escalationRuleRepository) GetEscalationAlerts(ctx context.Context, studentNSN string) (*[]domain.EscalationAlert, error) {
+	var alerts []domain.EscalationAlert
+
+	query := r.db.WithContext(ctx).Preload("EscalationRule").Preload("Student")
+	if studentNSN != "" {
+		query = query.Where("student_nsn = ?", studentNSN)
+	}
+
+	err := query.Order("created_at DESC").Find(&alerts).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get escalation alerts: %w", err)
+	}
+
+	return &alerts, nil
+}
+
+func checkEscalationRule(rule *domain.EscalationRule) error {
+	if rule.Period != domain.EscalationPeriodWeek && rule.Period != domain.EscalationPeriodMonth {
+		return fmt.Errorf("period %s is not supported, must be week or month", rule.Period)
+	}
+	if rule.Unit != domain.EscalationUnitCount && rule.Unit != domain.EscalationUnitPercent {
+		return fmt.Errorf("unit %s is not supported, must be count or percent", rule.Unit)
+	}
+	if rule.Threshold <= 0 {
+		return fmt.Errorf("threshold must be greater than zero")
+	}
+	if rule.Unit == domain.EscalationUnitPercent && rule.Threshold > 100 {
+		return fmt.Errorf("a percentage threshold cannot exceed 100")
+	}
+	if rule.MinSessions < 0 {
+		return fmt.Errorf("minimum sessions cannot be negative")
+	}
+	return nil
+}
+
+// escalationPeriodStart is the first day of the rule's period containing now, weeks start on Monday.
+func escalationPeriodStart(period string, now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if period == domain.EscalationPeriodMonth {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// evaluateEscalations checks the active rules for the students and records an alert for every rule
+// that fires for the first time in its current period, the new alerts are returned.
+// Absences are taken from the notification history so only notified, unexcused absences count.
+func evaluateEscalations(tx *gorm.DB, nsnList []string, now time.Time) ([]domain.EscalationAlert, error) {
+	var rules []domain.EscalationRule
+	if err := tx.Where("active = ?", true).Order("escalation_rule_id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("could not get escalation rules: %w", err)
+	}
+
+	var alerts []domain.EscalationAlert
+	for _, rule := range rules {
+		periodStart := escalationPeriodStart(rule.Period, now)
+		from := periodStart.Format("2006-01-02")
+		until := now.Format("2006-01-02")
+
+		for _, nsn := range nsnList {
+			var absences int64
+			err := tx.Model(&domain.AttendanceNotificationHistory{}).
+				Joins("JOIN attendances ON attendances.attendance_id = attendance_notification_histories.attendance_id").
+				Where("attendance_notification_histories.student_nsn = ? AND attendances.date BETWEEN ? AND ?", nsn, from, until).
+				Where("NOT EXISTS (SELECT 1 FROM excused_absences WHERE excused_absences.student_nsn = attendances.student_nsn AND excused_absences.status = ? AND attendances.date BETWEEN excused_absences.start_date AND excused_absences.end_date)", domain.ExcuseStatusApproved).
+				Distinct("attendances.attendance_id").
+				Count(&absences).Error
+			if err != nil {
+				return nil, fmt.Errorf("could not count absences of student %s: %w", nsn, err)
+			}
+
+			var sessions int64
+			err = tx.Model(&domain.Attendance{}).
+				Where("student_nsn = ? AND date BETWEEN ? AND ?", nsn, from, until).
+				Count(&sessions).Error
+			if err != nil {
+				return nil, fmt.Errorf("could not count sessions of student %s: %w", nsn, err)
+			}
+
+			if !ruleReached(rule, int(absences), int(sessions)) {
+				continue
+			}
+
+			alert := domain.EscalationAlert{
+				EscalationRuleID: rule.EscalationRuleID,
+				EscalationRule:   rule,
+				StudentNSN:       nsn,
+				PeriodStart:      periodStart,
+				Absences:         int(absences),
+				Sessions:         int(sessions),
+			}
+
+			// The unique index keeps a rule from firing twice for a student in the same period
+			result := tx.Omit("EscalationRule", "Student").Clauses(clause.OnConflict{DoNothing: true}).Create(&alert)
+			if result.Error != nil {
+				return nil, fmt.Errorf("could not record escalation of student %s: %w", nsn, result.Error)
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return alerts, nil
+}
+
+func ruleReached(rule domain.EscalationRule, absences, sessions int) bool {
+	if rule.Unit == domain.EscalationUnitCount {
+		return absences >= rule.Threshold
+	}
+	if sessions == 0 || sessions < rule.MinSessions {
+		return false
+	}
+	return absences*100 >= rule.Threshold*sessions
+}
@@ -0,0 +1,465 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification/domain"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type excusedAbsenceRepository struct {
+	db          *gorm.DB
+	schoolPhone string
+}
+
+// NewExcusedAbsenceRepository manages excuses entered by staff and the ones parents send through WhatsApp,
+// schoolPhone is quoted in the messages that acknowledge a parent's excuse.
+func NewExcusedAbsenceRepository(db *gorm.DB, schoolPhone string) domain.ExcusedAbsenceRepo {
+	return &excusedAbsenceRepository{
+		db:          db,
+		schoolPhone: schoolPhone,
+	}
+}
+
+func (r *excusedAbsenceRepository) GetAllExcusedAbsences(ctx context.Context, studentNSN, status string) (*[]domain.ExcusedAbsence, error) {
+	var excuses []domain.ExcusedAbsence
+
+	query := r.db.WithContext(ctx).Preload("Student")
+	if studentNSN != "" {
+		query = query.Where("student_nsn = ?", studentNSN)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.Order("start_date DESC").Find(&excuses).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get excused absences: %w", err)
+	}
+
+	return &excuses, nil
+}
+
+func (r *excusedAbsenceRepository) GetExcusedAbsenceByID(ctx context.Context, excusedAbsenceID int) (*domain.ExcusedAbsence, error) {
+	var excuse domain.ExcusedAbsence
+
+	err := r.db.WithContext(ctx).Preload("Student").Where("excused_absence_id = ?", excusedAbsenceID).First(&excuse).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("excused absence with ID %d not found", excusedAbsenceID)
+		}
+		return nil, fmt.Errorf("could not get excused absence: %w", err)
+	}
+
+	return &excuse, nil
+}
+
+func (r *excusedAbsenceRepository) CreateExcusedAbsence(ctx context.Context, payload *domain.ExcusedAbsencePayload, userID int) (*domain.ExcusedAbsence, error) {
+	startDate, endDate, err := parseExcuseRange(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var studentCount int64
+	err = r.db.WithContext(ctx).Model(&domain.Student{}).Where("student_nsn = ?", payload.StudentNSN).Count(&studentCount).Error
+	if err != nil {
+		return nil, fmt.Errorf("error checking student: %w", err)
+	}
+	if studentCount == 0 {
+		return nil, fmt.Errorf("student with StudentNSN %s not found", payload.StudentNSN)
+	}
+
+	excuse := domain.ExcusedAbsence{
+		StudentNSN: payload.StudentNSN,
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Reason:     strings.TrimSpace(payload.Reason),
+		Status:     domain.ExcuseStatusApproved,
+		Source:     domain.ExcuseSourceStaff,
+		UserID:     &userID,
+	}
+
+	if err := r.db.WithContext(ctx).Create(&excuse).Error; err != nil {
+		return nil, fmt.Errorf("could not create excused absence: %w", err)
+	}
+
+	return &excuse, nil
+}
+
+func (r *excusedAbsenceRepository) UpdateExcusedAbsence(ctx context.Context, excusedAbsenceID int, payload *domain.ExcusedAbsencePayload) error {
+	startDate, endDate, err := parseExcuseRange(payload)
+	if err != nil {
+		return err
+	}
+
+	// The student of an excuse cannot be changed, only its range and reason
+	result := r.db.WithContext(ctx).Model(&domain.ExcusedAbsence{}).
+		Where("excused_absence_id = ?", excusedAbsenceID).
+		Updates(map[string]interface{}{
+			"start_date": startDate,
+			"end_date":   endDate,
+			"reason":     strings.TrimSpace(payload.Reason),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("could not update excused absence: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("excused absence with ID %d not found", excusedAbsenceID)
+	}
+
+	return nil
+}
+
+func (r *excusedAbsenceRepository) DeleteExcusedAbsence(ctx context.Context, excusedAbsenceID int) error {
+	result := r.db.WithContext(ctx).Where("excused_absence_id = ?", excusedAbsenceID).Delete(&domain.ExcusedAbsence{})
+	if result.Error != nil {
+		return fmt.Errorf("could not delete excused absence: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("excused absence with ID %d not found", excusedAbsenceID)
+	}
+
+	return nil
+}
+
+func parseExcuseRange(payload *domain.ExcusedAbsencePayload) (time.Time, time.Time, error) {
+	startDate, err := time.Parse("2006-01-02", payload.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start date %s, expected format YYYY-MM-DD", payload.StartDate)
+	}
+
+	endDate, err := time.Parse("2006-01-02", payload.EndDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end date %s, expected format YYYY-MM-DD", payload.EndDate)
+	}
+
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end date %s is before start date %s", payload.EndDate, payload.StartDate)
+	}
+
+	return startDate, endDate, nil
+}
+
+// isExcused reports whether the student has an approved excuse covering the date.
+func isExcused(db *gorm.DB, studentNSN string, date time.Time) (bool, error) {
+	var count int64
+	day := date.Format("2006-01-02")
+	err := db.Model(&domain.ExcusedAbsence{}).
+		Where("student_nsn = ? AND status = ? AND start_date <= ? AND end_date >= ?", studentNSN, domain.ExcuseStatusApproved, day, day).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("could not check excused absences of student %s: %w", studentNSN, err)
+	}
+	return count > 0, nil
+}
+
+// ReviewExcusedAbsence approves or rejects a pending excuse, the parent who sent it is told the outcome.
+func (r *excusedAbsenceRepository) ReviewExcusedAbsence(ctx context.Context, excusedAbsenceID int, payload *domain.ExcuseReviewPayload, userID int) (*domain.ExcusedAbsence, error) {
+	if payload.Status != domain.ExcuseStatusApproved && payload.Status != domain.ExcuseStatusRejected {
+		return nil, fmt.Errorf("invalid review status %s, expected approved or rejected", payload.Status)
+	}
+
+	var excuse domain.ExcusedAbsence
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("excused_absence_id = ?", excusedAbsenceID).
+			First(&excuse).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("excused absence with ID %d not found", excusedAbsenceID)
+			}
+			return fmt.Errorf("could not get excused absence: %w", err)
+		}
+
+		if excuse.Status != domain.ExcuseStatusPending {
+			return fmt.Errorf("excused absence with ID %d is already %s", excusedAbsenceID, excuse.Status)
+		}
+
+		var note *string
+		if payload.Note != nil && strings.TrimSpace(*payload.Note) != "" {
+			trimmed := strings.TrimSpace(*payload.Note)
+			note = &trimmed
+		}
+
+		now := time.Now()
+		excuse.Status = payload.Status
+		excuse.ReviewedBy = &userID
+		excuse.ReviewedAt = &now
+		excuse.ReviewNote = note
+
+		err = tx.Model(&domain.ExcusedAbsence{}).
+			Where("excused_absence_id = ?", excusedAbsenceID).
+			Updates(map[string]interface{}{
+				"status":      excuse.Status,
+				"reviewed_by": userID,
+				"reviewed_at": now,
+				"review_note": note,
+				"updated_at":  now,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("could not review excused absence: %w", err)
+		}
+
+		if excuse.Source != domain.ExcuseSourceParent {
+			return nil
+		}
+
+		eventType := domain.EventExcuseApproved
+		if excuse.Status == domain.ExcuseStatusRejected {
+			eventType = domain.EventExcuseRejected
+		}
+		return r.queueExcuseMessage(tx, eventType, &excuse, &userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &excuse, nil
+}
+
+// excuseKeyword matches replies like "SAKIT", "izin 3", "izin 3hari", "Sakit 2 hari - demam" or "Sakit:demam":
+// the kind of excuse, an optional number of days with an optional unit and an optional explanation.
+var excuseKeyword = regexp.MustCompile(`(?is)^\s*(sakit|izin)(?:\s+(\d+)\s*(?:hari|hr|days?|d)?\b)?(?:(?:\s*[-:,.]\s*|\s+)(.*))?\s*$`)
+
+const (
+	// maxExcuseDays caps the days a parent can excuse with a keyword, longer leaves go through the school.
+	maxExcuseDays = 14
+
+	// maxRefusedDays bounds the days quoted back in a refusal, so an absurd count cannot overflow the dates.
+	maxRefusedDays = 999
+
+	// excuseNoticeWindow is how old the absence notice a keyword answers may be, an excuse is only
+	// taken for an absence the parent was told about.
+	excuseNoticeWindow = 7 * 24 * time.Hour
+)
+
+// SubmitParentExcuse turns a keyword reply into a pending excuse for the student of the absence notice
+// it answers, starting on the day of that absence. The parent is sent an acknowledgement, a refusal
+// when the reply asks for more than maxExcuseDays days, or the status of the excuse already covering
+// those days. A count of zero days is taken as one.
+// It returns nil when the reply is not a keyword, is refused, repeats an excuse, answers no recent
+// absence notice or the student cannot be told apart.
+func (r *excusedAbsenceRepository) SubmitParentExcuse(ctx context.Context, reply *domain.ParentReply) (*domain.ExcusedAbsence, error) {
+	match := excuseKeyword.FindStringSubmatch(reply.Body)
+	if match == nil {
+		return nil, nil
+	}
+
+	days := 1
+	if match[2] != "" {
+		var err error
+		days, err = strconv.Atoi(match[2])
+		if err != nil || days > maxRefusedDays {
+			days = maxRefusedDays
+		}
+		if days < 1 {
+			days = 1
+		}
+	}
+
+	reason := "Sakit"
+	if strings.EqualFold(match[1], "izin") {
+		reason = "Izin"
+	}
+	if detail := strings.TrimSpace(match[3]); detail != "" {
+		reason = fmt.Sprintf("%s: %s", reason, detail)
+	}
+	if runes := []rune(reason); len(runes) > 255 {
+		reason = string(runes[:255])
+	}
+
+	var excuse *domain.ExcusedAbsence
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		studentNSN, err := replyStudent(tx, reply)
+		if err != nil || studentNSN == "" {
+			return err
+		}
+
+		startDate, found, err := excuseStartDate(tx, reply, studentNSN)
+		if err != nil || !found {
+			return err
+		}
+		endDate := startDate.AddDate(0, 0, days-1)
+
+		if days > maxExcuseDays {
+			// Nothing is stored, the request is only answered
+			refused := domain.ExcusedAbsence{
+				StudentNSN: studentNSN,
+				StartDate:  startDate,
+				EndDate:    endDate,
+				Reason:     reason,
+			}
+			return r.queueExcuseMessage(tx, domain.EventExcuseTooLong, &refused, nil)
+		}
+
+		// A repeated keyword, or one for days staff already excused, is answered with the excuse on record
+		var existing domain.ExcusedAbsence
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("student_nsn = ? AND status IN ? AND start_date <= ? AND end_date >= ?",
+				studentNSN, []string{domain.ExcuseStatusPending, domain.ExcuseStatusApproved},
+				endDate.Format("2006-01-02"), startDate.Format("2006-01-02")).
+			Order("start_date").
+			First(&existing).Error
+		if err == nil {
+			eventType := domain.EventExcuseReceived
+			if existing.Status == domain.ExcuseStatusApproved {
+				eventType = domain.EventExcuseApproved
+			}
+			return r.queueExcuseMessage(tx, eventType, &existing, nil)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("could not check excused absences of student %s: %w", studentNSN, err)
+		}
+
+		excuse = &domain.ExcusedAbsence{
+			StudentNSN:    studentNSN,
+			StartDate:     startDate,
+			EndDate:       endDate,
+			Reason:        reason,
+			Status:        domain.ExcuseStatusPending,
+			Source:        domain.ExcuseSourceParent,
+			ParentReplyID: &reply.ParentReplyID,
+		}
+		if err := tx.Create(excuse).Error; err != nil {
+			return fmt.Errorf("could not create excused absence from reply %d: %w", reply.ParentReplyID, err)
+		}
+
+		return r.queueExcuseMessage(tx, domain.EventExcuseReceived, excuse, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return excuse, nil
+}
+
+// replyStudent returns the student a reply is about: the one of the answered notification, or the
+// parent's only child. An empty NSN means the parent has several children and did not quote a notice.
+func replyStudent(tx *gorm.DB, reply *domain.ParentReply) (string, error) {
+	if reply.StudentNSN != nil {
+		return *reply.StudentNSN, nil
+	}
+
+	var nsnList []string
+	err := tx.Model(&domain.Student{}).Where("parent_id = ?", reply.ParentID).Limit(2).Pluck("student_nsn", &nsnList).Error
+	if err != nil {
+		return "", fmt.Errorf("could not get students of parent %d: %w", reply.ParentID, err)
+	}
+	if len(nsnList) != 1 {
+		return "", nil
+	}
+	return nsnList[0], nil
+}
+
+// excuseStartDate is the day of the absence notice the reply answers, or of the student's latest notice
+// when it quotes none. found is false when the notice is older than excuseNoticeWindow or there is none.
+// Like attendance dates it is the school's calendar day at UTC midnight.
+func excuseStartDate(tx *gorm.DB, reply *domain.ParentReply, studentNSN string) (startDate time.Time, found bool, err error) {
+	query := tx.Where("student_nsn = ? AND parent_id = ? AND created_at >= ?", studentNSN, reply.ParentID, reply.ReceivedAt.Add(-excuseNoticeWindow))
+	if reply.NotificationHistoryID != nil {
+		query = query.Where("notification_history_id = ?", *reply.NotificationHistoryID)
+	}
+
+	var history domain.AttendanceNotificationHistory
+	err = query.Order("created_at DESC").First(&history).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, fmt.Errorf("could not get absence notice of reply %d: %w", reply.ParentReplyID, err)
+	}
+
+	if history.AttendanceID != nil {
+		var attendance domain.Attendance
+		err := tx.Where("attendance_id = ?", *history.AttendanceID).First(&attendance).Error
+		if err == nil {
+			return attendance.Date, true, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, false, fmt.Errorf("could not get attendance of reply %d: %w", reply.ParentReplyID, err)
+		}
+	}
+
+	setting, err := loadSchoolSetting(tx)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	day := history.CreatedAt.In(schoolNow(setting).Location())
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC), true, nil
+}
+
+// queueExcuseMessage queues a WhatsApp message about the excuse to the parent who sent it, under a job of its own.
+func (r *excusedAbsenceRepository) queueExcuseMessage(tx *gorm.DB, eventType string, excuse *domain.ExcusedAbsence, userID *int) error {
+	student, err := fetchStudentDetails(tx, excuse.StudentNSN)
+	if err != nil {
+		return err
+	}
+	if student.Parent.Telephone == "" {
+		return nil
+	}
+
+	templates, err := loadTemplateSet(tx, eventType)
+	if err != nil {
+		return err
+	}
+
+	language := messengerLanguage()
+	if student.Parent.PreferredLanguage != nil && templates.has(*student.Parent.PreferredLanguage, domain.ChannelWhatsApp) {
+		language = *student.Parent.PreferredLanguage
+	}
+
+	data := newTemplateData(time.Now(), r.schoolPhone)
+	data.Student = student.Student
+	data.Parent = student.Parent
+	data.Days = int(excuse.EndDate.Sub(excuse.StartDate).Hours()/24) + 1
+	data.StartDate = excuse.StartDate.Format("02/01/2006")
+	data.EndDate = excuse.EndDate.Format("02/01/2006")
+	data.Reason = excuse.Reason
+	if excuse.ReviewNote != nil {
+		data.Note = *excuse.ReviewNote
+	}
+
+	rendered, err := templates.render(language, domain.ChannelWhatsApp, data)
+	if err != nil {
+		return fmt.Errorf("failed to render %s message for student %s: %w", eventType, excuse.StudentNSN, err)
+	}
+
+	job := domain.NotificationJob{
+		JobID:           uuid.NewString(),
+		EventType:       eventType,
+		UserID:          userID,
+		TotalRecipients: 1,
+		TotalMessages:   1,
+	}
+	msg := domain.OutboxMessage{
+		JobID:              job.JobID,
+		EventType:          eventType,
+		Channel:            domain.ChannelWhatsApp,
+		ParentID:           student.Parent.ParentID,
+		StudentNSN:         excuse.StudentNSN,
+		RecipientName:      student.Parent.Name,
+		RecipientTelephone: student.Parent.Telephone,
+		Subject:            rendered.Subject,
+		Body:               rendered.Body,
+		Status:             domain.OutboxStatusPending,
+	}
+
+	if err := tx.Create(&msg).Error; err != nil {
+		return fmt.Errorf("failed to queue %s message for student %s: %w", eventType, excuse.StudentNSN, err)
+	}
+	if err := tx.Create(&job).Error; err != nil {
+		return fmt.Errorf("failed to create notification job: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"notification/domain"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -50,15 +51,71 @@ func (np *notificationRepo) GetAllAttendanceNotificationHistory(ctx context.Cont
 
 		// Append to final response slice
 		finalDatas = append(finalDatas, domain.AttendanceNotificationHistoryResponse{
-			Student:        record.Student,
-			Parent:         record.Parent,
-			User:           userResponse,
-			Subject:        record.Subject,
-			WhatsappStatus: record.WhatsappStatus,
-			EmailStatus:    record.EmailStatus,
-			CreatedAt:      record.CreatedAt,
+			Student:             record.Student,
+			Parent:              record.Parent,
+			User:                userResponse,
+			Subject:             record.Subject,
+			WhatsappStatus:      record.WhatsappStatus,
+			WhatsappDelivery:    record.WhatsAppDelivery(),
+			WhatsappSentAt:      record.WhatsappSentAt,
+			WhatsappDeliveredAt: record.WhatsappDeliveredAt,
+			WhatsappReadAt:      record.WhatsappReadAt,
+			EmailStatus:         record.EmailStatus,
+			SMSStatus:           record.SMSStatus,
+			CreatedAt:           record.CreatedAt,
 		})
 	}
 
 	return &finalDatas, nil
 }
+
+// RecordWhatsAppReceipt stamps the history rows of the given WhatsApp messages as delivered or read.
+// A read receipt implies delivery, and a receipt never moves a timestamp that is already set, so recording
+// the same receipt again is harmless. It returns the ids no history row carries (yet).
+func (np *notificationRepo) RecordWhatsAppReceipt(ctx context.Context, messageIDs []string, status string, at time.Time) ([]string, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	updates := map[string]interface{}{
+		"whatsapp_delivered_at": gorm.Expr("COALESCE(whatsapp_delivered_at, ?)", at),
+	}
+	switch status {
+	case domain.WhatsAppDeliveryDelivered:
+	case domain.WhatsAppDeliveryRead:
+		updates["whatsapp_read_at"] = gorm.Expr("COALESCE(whatsapp_read_at, ?)", at)
+	default:
+		return nil, fmt.Errorf("unknown whatsapp receipt status: %s", status)
+	}
+
+	var matched []string
+	err := np.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.AttendanceNotificationHistory{}).
+			Where("whatsapp_message_id IN ?", messageIDs).
+			Updates(updates).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.AttendanceNotificationHistory{}).
+			Where("whatsapp_message_id IN ?", messageIDs).
+			Distinct().
+			Pluck("whatsapp_message_id", &matched).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record whatsapp receipt: %w", err)
+	}
+
+	found := make(map[string]bool, len(matched))
+	for _, id := range matched {
+		found[id] = true
+	}
+
+	var unmatched []string
+	for _, id := range messageIDs {
+		if !found[id] {
+			unmatched = append(unmatched, id)
+		}
+	}
+	return unmatched, nil
+}
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"notification/domain"
+	"notification/mailer"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+type notifierRegistry struct {
+	mu        sync.RWMutex
+	enabled   map[string]bool
+	notifiers map[string]domain.Notifier
+	log       *logrus.Logger
+}
+
+// NewNotifierRegistry creates a registry that only accepts the given channels,
+// an empty list means every registered channel is enabled.
+func NewNotifierRegistry(enabledChannels []string, log *logrus.Logger) domain.NotifierRegistry {
+	enabled := make(map[string]bool, len(enabledChannels))
+	for _, channel := range enabledChannels {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if channel != "" {
+			enabled[channel] = true
+		}
+	}
+
+	return &notifierRegistry{
+		enabled:   enabled,
+		notifiers: make(map[string]domain.Notifier),
+		log:       log,
+	}
+}
+
+func (r *notifierRegistry) Register(notifier domain.Notifier) {
+	if notifier == nil {
+		return
+	}
+
+	channel := notifier.Channel()
+	if len(r.enabled) > 0 && !r.enabled[channel] {
+		r.log.Infof("Notifier channel %s is disabled, skipping", channel)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifiers[channel] = notifier
+}
+
+func (r *notifierRegistry) Get(channel string) (domain.Notifier, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	notifier, ok := r.notifiers[channel]
+	return notifier, ok
+}
+
+func (r *notifierRegistry) Channels() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	channels := make([]string, 0, len(r.notifiers))
+	for channel := range r.notifiers {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// Email
+type emailNotifier struct {
+	transport   *mailer.Transport
+	emailSender string
+}
+
+func NewEmailNotifier(transport *mailer.Transport, emailSender string) domain.Notifier {
+	return &emailNotifier{
+		transport:   transport,
+		emailSender: emailSender,
+	}
+}
+
+func (n *emailNotifier) Channel() string {
+	return domain.ChannelEmail
+}
+
+func (n *emailNotifier) Send(ctx context.Context, recipient domain.Recipient, message domain.Message) (*domain.SendResult, error) {
+	if recipient.Email == nil || *recipient.Email == "" {
+		return nil, fmt.Errorf("recipient %s has no email address", recipient.Name)
+	}
+
+	from, err := mail.ParseAddress(n.emailSender)
+	if err != nil {
+		from = &mail.Address{Address: n.emailSender}
+	}
+
+	email := mailer.Message{
+		From:    *from,
+		To:      []mail.Address{{Name: recipient.Name, Address: *recipient.Email}},
+		Subject: message.Subject,
+		Text:    message.Body,
+		HTML:    mailer.HTMLFromText(message.Body),
+	}
+	for _, attachment := range message.Attachments {
+		email.Attachments = append(email.Attachments, mailer.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Data:        attachment.Data,
+		})
+	}
+
+	result, err := n.transport.Send(ctx, &email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send email: %w", err)
+	}
+
+	// The transport succeeds once any recipient is accepted, a rejected one must not pass as sent
+	for _, status := range result.Recipients {
+		if !status.Accepted() {
+			return nil, fmt.Errorf("failed to send email: %w", &mailer.SMTPError{Address: status.Address, Code: status.Code, Message: status.Message})
+		}
+	}
+
+	return &domain.SendResult{MessageID: result.MessageID, SentAt: time.Now(), Response: smtpResponse(result)}, nil
+}
+
+// smtpResponse records the reply of the server to every recipient and to the data, e.g.
+// "RCPT budi@example.com: 250 2.1.5 Ok; DATA: 250 2.0.0 Ok: queued as 4F2A1".
+func smtpResponse(result *mailer.Result) string {
+	replies := make([]string, 0, len(result.Recipients)+1)
+	for _, status := range result.Recipients {
+		replies = append(replies, fmt.Sprintf("RCPT %s: %d %s", status.Address, status.Code, status.Message))
+	}
+	replies = append(replies, fmt.Sprintf("DATA: %d %s", result.Code, result.Response))
+	return strings.Join(replies, "; ")
+}
+
+// WhatsApp
+type whatsappNotifier struct {
+	client *whatsmeow.Client
+
+	mu         sync.Mutex
+	registered map[string]registration
+}
+
+// registration caches whether a number is on WhatsApp, so the lookup is not repeated for every message.
+type registration struct {
+	isIn      bool
+	checkedAt time.Time
+}
+
+// registrationTTL is how long a lookup is trusted, a parent may join or leave WhatsApp in the meantime.
+const registrationTTL = 24 * time.Hour
+
+func NewWhatsAppNotifier(client *whatsmeow.Client) domain.Notifier {
+	return &whatsappNotifier{
+		client:     client,
+		registered: make(map[string]registration),
+	}
+}
+
+func (n *whatsappNotifier) Channel() string {
+	return domain.ChannelWhatsApp
+}
+
+// Healthy reports whether the client can send right now, it is false while reconnecting or logged out.
+func (n *whatsappNotifier) Healthy() bool {
+	return n.client.IsConnected() && n.client.IsLoggedIn()
+}
+
+func (n *whatsappNotifier) Send(ctx context.Context, recipient domain.Recipient, message domain.Message) (*domain.SendResult, error) {
+	jid, err := telephoneToJID(recipient.Telephone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrying cannot help a number that is not on WhatsApp, a failed lookup is left to the send itself
+	if isIn, ok := n.onWhatsApp(jid); ok && !isIn {
+		return nil, notOnWhatsAppError{telephone: recipient.Telephone}
+	}
+
+	if len(message.Attachments) > 0 {
+		return n.sendDocuments(ctx, jid, message)
+	}
+
+	body := message.Body
+	conversationMessage := &waE2E.Message{
+		Conversation: &body,
+	}
+
+	resp, err := n.client.SendMessage(ctx, jid, conversationMessage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send whatsapp message: %w", err)
+	}
+	return &domain.SendResult{
+		MessageID: resp.ID,
+		SentAt:    resp.Timestamp,
+	}, nil
+}
+
+// messageCount is the number of WhatsApp messages a send takes, one per attachment and one for the text.
+// The rate limiter charges it so the cap holds per message that reaches WhatsApp, not per outbox message.
+func (n *whatsappNotifier) messageCount(message domain.Message) int {
+	return len(message.Attachments) + 1
+}
+
+// onWhatsApp reports whether the number is registered on WhatsApp, ok is false when the lookup failed.
+func (n *whatsappNotifier) onWhatsApp(jid types.JID) (isIn bool, ok bool) {
+	n.mu.Lock()
+	cached, found := n.registered[jid.User]
+	n.mu.Unlock()
+	if found && time.Since(cached.checkedAt) < registrationTTL {
+		return cached.isIn, true
+	}
+
+	registered, err := n.client.IsOnWhatsApp([]string{"+" + jid.User})
+	if err != nil || len(registered) == 0 {
+		return false, false
+	}
+
+	n.mu.Lock()
+	n.registered[jid.User] = registration{isIn: registered[0].IsIn, checkedAt: time.Now()}
+	n.mu.Unlock()
+	return registered[0].IsIn, true
+}
+
+// notOnWhatsAppError is a permanent failure, the outbox gives the message up (and falls back to SMS) right away.
+type notOnWhatsAppError struct {
+	telephone string
+}
+
+func (e notOnWhatsAppError) Error() string {
+	return fmt.Sprintf("telephone %s is not registered on whatsapp", e.telephone)
+}
+
+func (e notOnWhatsAppError) Permanent() bool {
+	return true
+}
+
+// sendDocuments uploads every attachment before anything is sent, then sends the documents and the
+// text last. A failed upload sends nothing, so a retry of the message cannot repeat the text, and the
+// result is the text message's since that is the one parents reply to.
+func (n *whatsappNotifier) sendDocuments(ctx context.Context, jid types.JID, message domain.Message) (*domain.SendResult, error) {
+	documents := make([]*waE2E.DocumentMessage, 0, len(message.Attachments))
+	for _, attachment := range message.Attachments {
+		uploaded, err := n.client.Upload(ctx, attachment.Data, whatsmeow.MediaDocument)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s to whatsapp: %w", attachment.Filename, err)
+		}
+
+		filename, contentType := attachment.Filename, attachment.ContentType
+		documents = append(documents, &waE2E.DocumentMessage{
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    &uploaded.FileLength,
+			Mimetype:      &contentType,
+			FileName:      &filename,
+			Title:         &filename,
+		})
+	}
+
+	for _, document := range documents {
+		if _, err := n.client.SendMessage(ctx, jid, &waE2E.Message{DocumentMessage: document}); err != nil {
+			return nil, fmt.Errorf("failed to send whatsapp document %s: %w", document.GetFileName(), err)
+		}
+	}
+
+	body := message.Body
+	resp, err := n.client.SendMessage(ctx, jid, &waE2E.Message{Conversation: &body})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send whatsapp message: %w", err)
+	}
+	return &domain.SendResult{
+		MessageID: resp.ID,
+		SentAt:    resp.Timestamp,
+	}, nil
+}
+
+// telephoneToJID converts a local number (08xx) into an Indonesian WhatsApp JID (628xx).
+func telephoneToJID(telephone string) (types.JID, error) {
+	completeFormat, err := internationalTelephone(telephone)
+	if err != nil {
+		return types.JID{}, err
+	}
+
+	return types.NewJID(completeFormat, types.DefaultUserServer), nil
+}
+
+// internationalTelephone converts a local number (08xx) into the international form without plus (628xx).
+func internationalTelephone(telephone string) (string, error) {
+	if len(telephone) < 2 {
+		return "", fmt.Errorf("invalid telephone number: %s", telephone)
+	}
+
+	completeFormat := strings.TrimPrefix(telephone, "+")
+	if strings.HasPrefix(completeFormat, "0") {
+		completeFormat = fmt.Sprintf("%s%s", "62", completeFormat[1:])
+	}
+
+	return completeFormat, nil
+}
+
+// telephoneCandidates returns the forms an international number (628xx) may be stored in
+// for a parent: as is, with a leading plus or as a local number (08xx).
+func telephoneCandidates(international string) []string {
+	candidates := []string{international, "+" + international}
+	if strings.HasPrefix(international, "62") {
+		candidates = append(candidates, "0"+international[2:])
+	}
+	return candidates
+}
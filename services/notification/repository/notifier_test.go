@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"notification/domain"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// fakeNotifier records what it was asked to send instead of reaching a live channel.
+type fakeNotifier struct {
+	channel string
+
+	mu   sync.Mutex
+	sent []fakeDelivery
+}
+
+type fakeDelivery struct {
+	recipient domain.Recipient
+	message   domain.Message
+}
+
+func (n *fakeNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *fakeNotifier) Send(ctx context.Context, recipient domain.Recipient, message domain.Message) (*domain.SendResult, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, fakeDelivery{recipient: recipient, message: message})
+	return &domain.SendResult{SentAt: time.Now()}, nil
+}
+
+func TestNotifierRegistry(t *testing.T) {
+	registry := NewNotifierRegistry([]string{" Email ", "whatsapp"}, logrus.New())
+
+	email := &fakeNotifier{channel: domain.ChannelEmail}
+	whatsapp := &fakeNotifier{channel: domain.ChannelWhatsApp}
+	sms := &fakeNotifier{channel: domain.ChannelSMS}
+
+	registry.Register(email)
+	registry.Register(whatsapp)
+	registry.Register(sms)
+	registry.Register(nil)
+
+	if got, ok := registry.Get(domain.ChannelEmail); !ok || got != email {
+		t.Errorf("Get(email) = %v, %v, want the email notifier", got, ok)
+	}
+	if got, ok := registry.Get(domain.ChannelWhatsApp); !ok || got != whatsapp {
+		t.Errorf("Get(whatsapp) = %v, %v, want the whatsapp notifier", got, ok)
+	}
+	if _, ok := registry.Get(domain.ChannelSMS); ok {
+		t.Errorf("Get(sms) found a notifier for a disabled channel")
+	}
+
+	want := []string{domain.ChannelEmail, domain.ChannelWhatsApp}
+	if got := registry.Channels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Channels() = %v, want %v", got, want)
+	}
+}
+
+func TestNotifierRegistryEnablesEveryChannelByDefault(t *testing.T) {
+	registry := NewNotifierRegistry(nil, logrus.New())
+
+	sms := &fakeNotifier{channel: domain.ChannelSMS}
+	registry.Register(sms)
+
+	notifier, ok := registry.Get(domain.ChannelSMS)
+	if !ok {
+		t.Fatalf("Get(sms) found nothing although no channel list was given")
+	}
+
+	recipient := domain.Recipient{Name: "Ibu Siti", Telephone: "081234567"}
+	if _, err := notifier.Send(context.Background(), recipient, domain.Message{Body: "Halo"}); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if len(sms.sent) != 1 || sms.sent[0].recipient != recipient || sms.sent[0].message.Body != "Halo" {
+		t.Errorf("fake recorded %+v", sms.sent)
+	}
+}
@@ -0,0 +1,430 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification/domain"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type outboxRepository struct {
+	db            *gorm.DB
+	notifiers     domain.NotifierRegistry
+	lease         time.Duration
+	retry         domain.RetryPolicy
+	fallbackAfter time.Duration
+	log           *logrus.Logger
+}
+
+// NewOutboxRepository creates the outbox dispatcher, lease is how long a claimed
+// message stays locked before another worker may pick it up again (e.g. after a crash).
+// fallbackAfter is how long a message may wait on a paused channel before its fallback is queued.
+func NewOutboxRepository(db *gorm.DB, notifiers domain.NotifierRegistry, lease time.Duration, retry domain.RetryPolicy, fallbackAfter time.Duration, log *logrus.Logger) domain.OutboxRepo {
+	return &outboxRepository{
+		db:            db,
+		notifiers:     notifiers,
+		lease:         lease,
+		retry:         retry,
+		fallbackAfter: fallbackAfter,
+		log:           log,
+	}
+}
+
+func (o *outboxRepository) DispatchNext(ctx context.Context) (bool, error) {
+	setting, err := loadSchoolSetting(o.db.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+
+	// Messages stay pending through the quiet hours and go out once they are over
+	if inQuietHours(setting, schoolNow(setting)) {
+		return false, nil
+	}
+
+	paused := o.pausedChannels()
+	if err := o.fallbackPaused(ctx, paused); err != nil {
+		return false, err
+	}
+
+	msg, err := o.claimNext(ctx, paused)
+	if err != nil {
+		return false, err
+	}
+	if msg == nil {
+		return false, nil
+	}
+
+	notifier, ok := o.notifiers.Get(msg.Channel)
+	if !ok {
+		// Retrying will not enable the channel, bury it right away
+		return true, o.markDead(ctx, msg, fmt.Errorf("channel %s is not enabled", msg.Channel))
+	}
+
+	result, err := notifier.Send(ctx, msg.Recipient(), msg.Message())
+	if err != nil {
+		var throttled *domain.Throttled
+		if errors.As(err, &throttled) {
+			return true, o.release(ctx, msg, throttled.RetryAfter)
+		}
+
+		// A rejected mailbox stays rejected, retrying would only delay the dead letter
+		var failure domain.PermanentFailure
+		if errors.As(err, &failure) && failure.Permanent() {
+			msg.Attempts++
+			return true, o.markDead(ctx, msg, err)
+		}
+		return true, o.markFailed(ctx, msg, err)
+	}
+
+	return true, o.markSent(ctx, msg, result)
+}
+
+// pausedChannels returns the channels whose notifier reports itself unhealthy, e.g. WhatsApp while
+// the client reconnects. Their messages wait in the outbox and keep their attempts.
+func (o *outboxRepository) pausedChannels() []string {
+	var paused []string
+	for _, channel := range o.notifiers.Channels() {
+		notifier, ok := o.notifiers.Get(channel)
+		if !ok {
+			continue
+		}
+		if checker, ok := notifier.(domain.HealthChecker); ok && !checker.Healthy() {
+			paused = append(paused, channel)
+		}
+	}
+	return paused
+}
+
+// claimNext locks the oldest due pending message (or one whose lease expired) so that
+// concurrent workers never send the same message twice. Messages of a paused channel are left alone.
+func (o *outboxRepository) claimNext(ctx context.Context, paused []string) (*domain.OutboxMessage, error) {
+	var msg domain.OutboxMessage
+	now := time.Now()
+
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("((status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)) OR (status = ? AND locked_until < ?))",
+				domain.OutboxStatusPending, now, domain.OutboxStatusProcessing, now)
+		if len(paused) > 0 {
+			query = query.Where("channel NOT IN ?", paused)
+		}
+
+		err := query.Order("outbox_message_id").First(&msg).Error
+		if err != nil {
+			return err
+		}
+
+		lockedUntil := now.Add(o.lease)
+		msg.Status = domain.OutboxStatusProcessing
+		msg.LockedUntil = &lockedUntil
+
+		return tx.Model(&domain.OutboxMessage{}).
+			Where("outbox_message_id = ?", msg.OutboxMessageID).
+			Updates(map[string]interface{}{
+				"status":       domain.OutboxStatusProcessing,
+				"locked_until": lockedUntil,
+			}).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim outbox message: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// markSent closes the message with the reply of the channel and flags its notification history, WhatsApp
+// history also keeps the message id so delivery and read receipts can be matched to it later.
+func (o *outboxRepository) markSent(ctx context.Context, msg *domain.OutboxMessage, result *domain.SendResult) error {
+	now := time.Now()
+
+	sent := map[string]interface{}{
+		"status":       domain.OutboxStatusSent,
+		"sent_at":      now,
+		"locked_until": nil,
+		"last_error":   nil,
+	}
+	if result != nil && result.Response != "" {
+		sent["response"] = result.Response
+	}
+
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.OutboxMessage{}).
+			Where("outbox_message_id = ?", msg.OutboxMessageID).
+			Updates(sent).Error
+		if err != nil {
+			return fmt.Errorf("failed to mark outbox message %d as sent: %w", msg.OutboxMessageID, err)
+		}
+
+		if msg.NotificationHistoryID == nil && msg.EventType != domain.EventAbsenceDigest {
+			return nil
+		}
+
+		var updates map[string]interface{}
+		switch msg.Channel {
+		case domain.ChannelEmail:
+			updates = map[string]interface{}{"email_status": true}
+		case domain.ChannelWhatsApp:
+			updates = map[string]interface{}{"whatsapp_status": true}
+			if result != nil && result.MessageID != "" {
+				updates["whatsapp_message_id"] = result.MessageID
+				updates["whatsapp_sent_at"] = result.SentAt
+			}
+		case domain.ChannelSMS:
+			updates = map[string]interface{}{"sms_status": true}
+		default:
+			return nil
+		}
+
+		history := tx.Model(&domain.AttendanceNotificationHistory{})
+		if msg.EventType == domain.EventAbsenceDigest {
+			// A digest covers every absence of the student logged under the job
+			history = history.Where("job_id = ? AND student_nsn = ?", msg.JobID, msg.StudentNSN)
+		} else {
+			history = history.Where("notification_history_id = ?", *msg.NotificationHistoryID)
+		}
+
+		err = history.Updates(updates).Error
+		if err != nil {
+			return fmt.Errorf("failed to update notification history of outbox message %d: %w", msg.OutboxMessageID, err)
+		}
+		return nil
+	})
+}
+
+// release puts a message that was not sent back in the queue, due after retryAfter and with its attempts unchanged.
+func (o *outboxRepository) release(ctx context.Context, msg *domain.OutboxMessage, retryAfter time.Duration) error {
+	err := o.db.WithContext(ctx).Model(&domain.OutboxMessage{}).
+		Where("outbox_message_id = ?", msg.OutboxMessageID).
+		Updates(map[string]interface{}{
+			"status":          domain.OutboxStatusPending,
+			"next_attempt_at": time.Now().Add(retryAfter),
+			"locked_until":    nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to put outbox message %d back: %w", msg.OutboxMessageID, err)
+	}
+	return nil
+}
+
+// markFailed schedules the next attempt with exponential backoff, or marks the
+// message dead once it ran out of attempts. The fallback is queued on the first failure already,
+// so a parent is not kept waiting through the whole backoff.
+func (o *outboxRepository) markFailed(ctx context.Context, msg *domain.OutboxMessage, sendErr error) error {
+	attempts := msg.Attempts + 1
+	if attempts >= o.retry.MaxAttempts {
+		msg.Attempts = attempts
+		return o.markDead(ctx, msg, sendErr)
+	}
+
+	errMsg := sendErr.Error()
+	nextAttemptAt := time.Now().Add(o.retry.Backoff(attempts))
+	o.log.Warnf("Failed to send %s message %d to %s (attempt %d/%d), retrying at %s: %s",
+		msg.Channel, msg.OutboxMessageID, msg.RecipientName, attempts, o.retry.MaxAttempts, nextAttemptAt.Format("15:04:05"), errMsg)
+
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.OutboxMessage{}).
+			Where("outbox_message_id = ?", msg.OutboxMessageID).
+			Updates(map[string]interface{}{
+				"status":          domain.OutboxStatusPending,
+				"attempts":        attempts,
+				"next_attempt_at": nextAttemptAt,
+				"last_error":      errMsg,
+				"locked_until":    nil,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to reschedule outbox message %d: %w", msg.OutboxMessageID, err)
+		}
+
+		return o.queueFallback(tx, msg)
+	})
+}
+
+// markDead buries the message and, when its channel has a fallback, queues it again on that channel.
+func (o *outboxRepository) markDead(ctx context.Context, msg *domain.OutboxMessage, sendErr error) error {
+	errMsg := sendErr.Error()
+	o.log.Errorf("Giving up on %s message %d to %s after %d attempts: %s", msg.Channel, msg.OutboxMessageID, msg.RecipientName, msg.Attempts, errMsg)
+
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.OutboxMessage{}).
+			Where("outbox_message_id = ?", msg.OutboxMessageID).
+			Updates(map[string]interface{}{
+				"status":          domain.OutboxStatusDead,
+				"attempts":        msg.Attempts,
+				"next_attempt_at": nil,
+				"last_error":      errMsg,
+				"locked_until":    nil,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to mark outbox message %d as dead: %w", msg.OutboxMessageID, err)
+		}
+
+		return o.queueFallback(tx, msg)
+	})
+}
+
+// fallbackPaused queues the fallback of messages that have waited longer than fallbackAfter on a paused
+// channel, e.g. while WhatsApp is logged out. They stay queued and still go out once the channel is back.
+func (o *outboxRepository) fallbackPaused(ctx context.Context, paused []string) error {
+	for _, channel := range paused {
+		fallback, ok := fallbackChannels[channel]
+		if !ok {
+			continue
+		}
+		if _, ok := o.notifiers.Get(fallback); !ok {
+			continue
+		}
+
+		var waiting []domain.OutboxMessage
+		err := o.db.WithContext(ctx).
+			Where("channel = ? AND status = ? AND created_at < ? AND recipient_telephone <> ''", channel, domain.OutboxStatusPending, time.Now().Add(-o.fallbackAfter)).
+			Where("parent_id IN (SELECT parent_id FROM parents WHERE notify_sms = ? AND deleted_at IS NULL)", true).
+			Where(`NOT EXISTS (SELECT 1 FROM outbox_messages f WHERE f.job_id = outbox_messages.job_id AND f.channel = ?
+				AND f.parent_id = outbox_messages.parent_id AND f.student_nsn = outbox_messages.student_nsn
+				AND f.event_type = outbox_messages.event_type
+				AND f.notification_history_id IS NOT DISTINCT FROM outbox_messages.notification_history_id)`, fallback).
+			Order("outbox_message_id").
+			Limit(50).
+			Find(&waiting).Error
+		if err != nil {
+			return fmt.Errorf("failed to get %s messages waiting for a fallback: %w", channel, err)
+		}
+
+		for i := range waiting {
+			err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				return o.queueFallback(tx, &waiting[i])
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fallbackChannels is where a message goes once its own channel failed on it, parents that are not
+// on WhatsApp still get a text message.
+var fallbackChannels = map[string]string{
+	domain.ChannelWhatsApp: domain.ChannelSMS,
+}
+
+// queueFallback copies the text of a failed message into a new message on the fallback channel, if that
+// channel is enabled, the parent still exists and did not turn it off, and the message was not handed over before.
+func (o *outboxRepository) queueFallback(tx *gorm.DB, msg *domain.OutboxMessage) error {
+	channel, ok := fallbackChannels[msg.Channel]
+	if !ok || msg.RecipientTelephone == "" {
+		return nil
+	}
+	if _, ok := o.notifiers.Get(channel); !ok {
+		return nil
+	}
+
+	var parents []domain.Parent
+	if err := tx.Where("parent_id = ? AND deleted_at IS NULL", msg.ParentID).Limit(1).Find(&parents).Error; err != nil {
+		return fmt.Errorf("failed to get parent of outbox message %d: %w", msg.OutboxMessageID, err)
+	}
+	if len(parents) == 0 || !parents[0].ChannelEnabled(channel) {
+		return nil
+	}
+
+	// Every retry and a redriven message that fails again must not text the parent twice
+	var queued int64
+	err := tx.Model(&domain.OutboxMessage{}).
+		Where("job_id = ? AND channel = ? AND parent_id = ? AND student_nsn = ? AND event_type = ? AND notification_history_id IS NOT DISTINCT FROM ?",
+			msg.JobID, channel, msg.ParentID, msg.StudentNSN, msg.EventType, msg.NotificationHistoryID).
+		Count(&queued).Error
+	if err != nil {
+		return fmt.Errorf("failed to check fallback of outbox message %d: %w", msg.OutboxMessageID, err)
+	}
+	if queued > 0 {
+		return nil
+	}
+
+	fallback := domain.OutboxMessage{
+		JobID:                 msg.JobID,
+		EventType:             msg.EventType,
+		Channel:               channel,
+		ParentID:              msg.ParentID,
+		StudentNSN:            msg.StudentNSN,
+		RecipientName:         msg.RecipientName,
+		RecipientTelephone:    msg.RecipientTelephone,
+		RecipientEmail:        msg.RecipientEmail,
+		Body:                  msg.Body,
+		Status:                domain.OutboxStatusPending,
+		NotificationHistoryID: msg.NotificationHistoryID,
+		FallbackOfID:          &msg.OutboxMessageID,
+	}
+	// Workers sweeping at the same time can all pass the check above, the unique index on the source
+	// message and channel lets only one of them queue the fallback
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&fallback)
+	if result.Error != nil {
+		return fmt.Errorf("failed to queue %s fallback of outbox message %d: %w", channel, msg.OutboxMessageID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	o.log.Infof("Queued %s message %d to %s as fallback of %s message %d", channel, fallback.OutboxMessageID, msg.RecipientName, msg.Channel, msg.OutboxMessageID)
+	return nil
+}
+
+func (o *outboxRepository) GetDeadMessages(ctx context.Context) (*[]domain.OutboxMessage, error) {
+	var msgs []domain.OutboxMessage
+
+	err := o.db.WithContext(ctx).
+		Where("status = ?", domain.OutboxStatusDead).
+		Order("updated_at DESC").
+		Find(&msgs).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get dead outbox messages: %w", err)
+	}
+
+	return &msgs, nil
+}
+
+func (o *outboxRepository) RedriveDeadMessage(ctx context.Context, outboxMessageID int) error {
+	result := o.db.WithContext(ctx).Model(&domain.OutboxMessage{}).
+		Where("outbox_message_id = ? AND status = ?", outboxMessageID, domain.OutboxStatusDead).
+		Updates(redriveFields())
+	if result.Error != nil {
+		return fmt.Errorf("failed to redrive outbox message %d: %w", outboxMessageID, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no dead outbox message found with id %d", outboxMessageID)
+	}
+
+	return nil
+}
+
+// RedriveDeadMessages puts every dead message (of a single job when jobID is set) back in the queue.
+func (o *outboxRepository) RedriveDeadMessages(ctx context.Context, jobID string) (int64, error) {
+	query := o.db.WithContext(ctx).Model(&domain.OutboxMessage{}).Where("status = ?", domain.OutboxStatusDead)
+	if jobID != "" {
+		query = query.Where("job_id = ?", jobID)
+	}
+
+	result := query.Updates(redriveFields())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to redrive dead outbox messages: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func redriveFields() map[string]interface{} {
+	return map[string]interface{}{
+		"status":          domain.OutboxStatusPending,
+		"attempts":        0,
+		"next_attempt_at": nil,
+		"locked_until":    nil,
+	}
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"notification/domain"
+	"sync"
+	"time"
+)
+
+// rateLimitedNotifier paces a notifier with a token bucket. The registry holds a single instance per
+// channel, so the bucket is shared by every outbox worker and caps the channel as a whole.
+type rateLimitedNotifier struct {
+	domain.Notifier
+
+	mu       sync.Mutex
+	interval time.Duration
+	burst    float64
+	tokens   float64
+	last     time.Time
+	jitter   time.Duration
+	maxWait  time.Duration
+}
+
+// NewRateLimitedNotifier allows perMinute messages per minute with bursts of up to burst messages,
+// each send is delayed by up to jitter more so messages do not leave at a machine-like fixed pace.
+// A send that would wait longer than maxWait gives its turn up with a domain.Throttled error, maxWait
+// must stay below the outbox lease or another worker reclaims the message while it waits.
+// A notifier that sends several messages per call (see messageCounter) is charged a token for each.
+// A perMinute of zero or less returns the notifier unchanged.
+func NewRateLimitedNotifier(notifier domain.Notifier, perMinute, burst int, jitter, maxWait time.Duration) domain.Notifier {
+	if perMinute <= 0 {
+		return notifier
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return &rateLimitedNotifier{
+		Notifier: notifier,
+		interval: time.Minute / time.Duration(perMinute),
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+		jitter:   jitter,
+		maxWait:  maxWait,
+	}
+}
+
+// messageCounter is implemented by notifiers whose single Send may deliver several messages,
+// e.g. WhatsApp sends every attachment as a message of its own.
+type messageCounter interface {
+	messageCount(message domain.Message) int
+}
+
+func (n *rateLimitedNotifier) Send(ctx context.Context, recipient domain.Recipient, message domain.Message) (*domain.SendResult, error) {
+	cost := 1
+	if counter, ok := n.Notifier.(messageCounter); ok {
+		cost = counter.messageCount(message)
+	}
+
+	if err := n.wait(ctx, cost); err != nil {
+		return nil, err
+	}
+	return n.Notifier.Send(ctx, recipient, message)
+}
+
+// Healthy forwards the health of the wrapped notifier, the embedded interface does not expose it.
+func (n *rateLimitedNotifier) Healthy() bool {
+	if checker, ok := n.Notifier.(domain.HealthChecker); ok {
+		return checker.Healthy()
+	}
+	return true
+}
+
+// wait blocks until the caller may send cost messages, a caller that gives up hands its tokens back.
+func (n *rateLimitedNotifier) wait(ctx context.Context, cost int) error {
+	delay := n.reserve(cost)
+	if n.jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(n.jitter)))
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	if n.maxWait > 0 && delay > n.maxWait {
+		n.refund(cost)
+		return &domain.Throttled{RetryAfter: delay}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		n.refund(cost)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes cost tokens and returns how long until they are actually earned, the bucket goes
+// negative while callers queue up so they are served in order.
+func (n *rateLimitedNotifier) reserve(cost int) time.Duration {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	n.tokens = math.Min(n.burst, n.tokens+float64(now.Sub(n.last))/float64(n.interval))
+	n.last = now
+
+	n.tokens -= float64(cost)
+	if n.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-n.tokens * float64(n.interval))
+}
+
+func (n *rateLimitedNotifier) refund(cost int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.tokens = math.Min(n.burst, n.tokens+float64(cost))
+}
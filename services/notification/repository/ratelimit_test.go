@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"notification/domain"
+	"testing"
+	"time"
+)
+
+// countingNotifier is a fakeNotifier whose sends take one message per attachment plus the text, like WhatsApp.
+type countingNotifier struct {
+	fakeNotifier
+}
+
+func (n *countingNotifier) messageCount(message domain.Message) int {
+	return len(message.Attachments) + 1
+}
+
+func TestRateLimitedNotifier(t *testing.T) {
+	withAttachments := func(count int) domain.Message {
+		return domain.Message{Body: "Hello", Attachments: make([]domain.Attachment, count)}
+	}
+
+	tests := []struct {
+		name      string
+		counting  bool
+		burst     int
+		messages  []domain.Message
+		wantSent  int
+		throttled bool
+	}{
+		{name: "burst goes out at once", burst: 2, messages: []domain.Message{{}, {}}, wantSent: 2},
+		{name: "over the burst is throttled", burst: 2, messages: []domain.Message{{}, {}, {}}, wantSent: 2, throttled: true},
+		{name: "attachments are charged per message", counting: true, burst: 3, messages: []domain.Message{withAttachments(2), {}}, wantSent: 1, throttled: true},
+		{name: "plain notifiers are charged per send", burst: 2, messages: []domain.Message{withAttachments(2), {}}, wantSent: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeNotifier{channel: domain.ChannelWhatsApp}
+			var inner domain.Notifier = fake
+			if tt.counting {
+				counting := &countingNotifier{fakeNotifier{channel: domain.ChannelWhatsApp}}
+				fake, inner = &counting.fakeNotifier, counting
+			}
+
+			// One message a minute, a send that has to wait gives up after 50ms
+			notifier := NewRateLimitedNotifier(inner, 1, tt.burst, 0, 50*time.Millisecond)
+
+			var err error
+			for _, message := range tt.messages {
+				if _, err = notifier.Send(context.Background(), domain.Recipient{}, message); err != nil {
+					break
+				}
+			}
+
+			var throttled *domain.Throttled
+			if errors.As(err, &throttled) != tt.throttled {
+				t.Fatalf("Send error = %v, throttled %v", err, tt.throttled)
+			}
+			if throttled != nil && (throttled.RetryAfter <= 50*time.Millisecond || throttled.RetryAfter > time.Minute) {
+				t.Errorf("RetryAfter = %v, want the wait for the next token", throttled.RetryAfter)
+			}
+			if len(fake.sent) != tt.wantSent {
+				t.Errorf("sent %d messages, want %d", len(fake.sent), tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestRateLimitedNotifierRefundsGivenUpTurns(t *testing.T) {
+	fake := &fakeNotifier{channel: domain.ChannelWhatsApp}
+	notifier := NewRateLimitedNotifier(fake, 1, 1, 0, 50*time.Millisecond).(*rateLimitedNotifier)
+
+	if _, err := notifier.Send(context.Background(), domain.Recipient{}, domain.Message{}); err != nil {
+		t.Fatalf("first Send: %v", err)
+	}
+
+	// Every throttled send hands its token back, otherwise the wait would grow with each attempt
+	var first *domain.Throttled
+	for i := 0; i < 3; i++ {
+		_, err := notifier.Send(context.Background(), domain.Recipient{}, domain.Message{})
+		var throttled *domain.Throttled
+		if !errors.As(err, &throttled) {
+			t.Fatalf("Send %d error = %v, want Throttled", i, err)
+		}
+		if first == nil {
+			first = throttled
+		} else if throttled.RetryAfter > first.RetryAfter {
+			t.Errorf("RetryAfter grew from %v to %v", first.RetryAfter, throttled.RetryAfter)
+		}
+	}
+
+	// A caller that stops waiting hands its token back as well
+	ctx, cancel := context.WithCancel(context.Background())
+	notifier.maxWait = 0
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := notifier.wait(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("wait error = %v, want context.Canceled", err)
+	}
+	if notifier.tokens < -0.01 {
+		t.Errorf("tokens = %v after a cancelled wait, want the turn refunded", notifier.tokens)
+	}
+	if len(fake.sent) != 1 {
+		t.Errorf("sent %d messages, want 1", len(fake.sent))
+	}
+}
+
+func TestRateLimitedNotifierJitter(t *testing.T) {
+	fake := &fakeNotifier{channel: domain.ChannelWhatsApp}
+
+	// Tokens are plenty, only the jitter delays a send
+	notifier := NewRateLimitedNotifier(fake, 6000, 100, 20*time.Millisecond, 0)
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		if _, err := notifier.Send(context.Background(), domain.Recipient{}, domain.Message{}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 20*time.Millisecond+50*time.Millisecond {
+			t.Errorf("send took %v, want at most the jitter", elapsed)
+		}
+	}
+
+	// The jitter counts towards maxWait, an hour of it cannot fit in a millisecond
+	limited := NewRateLimitedNotifier(fake, 6000, 100, time.Hour, time.Millisecond).(*rateLimitedNotifier)
+	_, err := limited.Send(context.Background(), domain.Recipient{}, domain.Message{})
+	var throttled *domain.Throttled
+	if !errors.As(err, &throttled) || throttled.RetryAfter >= time.Hour {
+		t.Fatalf("Send error = %v, want Throttled below the jitter", err)
+	}
+	if limited.tokens < 99.99 {
+		t.Errorf("tokens = %v after a throttled send, want the turn refunded", limited.tokens)
+	}
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// receiptRetries is how often a receipt is recorded again when some of its messages matched no history,
+	// a phone may confirm a message before the outbox stored its id.
+	receiptRetries = 3
+
+	// receiptRetryDelay is the pause before each of those attempts.
+	receiptRetryDelay = 5 * time.Second
+)
+
+// whatsappReceipt is a receipt waiting to be recorded, attempt counts the tries so far.
+type whatsappReceipt struct {
+	messageIDs []string
+	status     string
+	at         time.Time
+	attempt    int
+}
+
+// ListenWhatsAppReceipts records the delivery and read receipts parents' phones send back
+// for our WhatsApp messages on the matching notification history. Like the replies they are
+// recorded one by one in the background, so the event handler never waits for the database.
+func ListenWhatsAppReceipts(client *whatsmeow.Client, notifications domain.NotificationRepo, log *logrus.Logger) {
+	receipts := make(chan whatsappReceipt, 100)
+
+	go func() {
+		for receipt := range receipts {
+			handleWhatsAppReceipt(notifications, receipts, receipt, log)
+		}
+	}()
+
+	client.AddEventHandler(func(rawEvt interface{}) {
+		evt, ok := rawEvt.(*events.Receipt)
+		if !ok || evt.IsFromMe || evt.IsGroup {
+			return
+		}
+
+		var status string
+		switch evt.Type {
+		case types.ReceiptTypeDelivered:
+			status = domain.WhatsAppDeliveryDelivered
+		case types.ReceiptTypeRead:
+			status = domain.WhatsAppDeliveryRead
+		default:
+			return
+		}
+
+		receipts <- whatsappReceipt{messageIDs: evt.MessageIDs, status: status, at: evt.Timestamp}
+	})
+}
+
+// handleWhatsAppReceipt records a receipt and queues its unmatched messages again after receiptRetryDelay.
+// Receipts for messages that never get a history row, such as excuse acknowledgements, run out of retries quietly.
+func handleWhatsAppReceipt(notifications domain.NotificationRepo, receipts chan<- whatsappReceipt, receipt whatsappReceipt, log *logrus.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	unmatched, err := notifications.RecordWhatsAppReceipt(ctx, receipt.messageIDs, receipt.status, receipt.at)
+	if err != nil {
+		log.Errorf("Failed to record whatsapp %s receipt: %v", receipt.status, err)
+		unmatched = receipt.messageIDs
+	}
+
+	if len(unmatched) == 0 || receipt.attempt >= receiptRetries {
+		return
+	}
+
+	retry := receipt
+	retry.messageIDs = unmatched
+	retry.attempt++
+	time.AfterFunc(receiptRetryDelay, func() {
+		receipts <- retry
+	})
+}
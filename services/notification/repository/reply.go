@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification/domain"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type parentReplyRepository struct {
+	db *gorm.DB
+}
+
+func NewParentReplyRepository(db *gorm.DB) domain.ParentReplyRepo {
+	return &parentReplyRepository{
+		db: db,
+	}
+}
+
+// RecordParentReply stores an inbound message of a known parent, messages from numbers that
+// belong to no parent are ignored and return nil. A message received twice is stored once, nil is returned the second time.
+func (r *parentReplyRepository) RecordParentReply(ctx context.Context, message *domain.InboundMessage) (*domain.ParentReply, error) {
+	var parent domain.Parent
+	err := r.db.WithContext(ctx).
+		Where("telephone IN ? AND deleted_at IS NULL", telephoneCandidates(message.Telephone)).
+		Order("parent_id").
+		First(&parent).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find parent of %s: %w", message.Telephone, err)
+	}
+
+	history, err := r.repliedNotification(ctx, parent.ParentID, message.QuotedMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := domain.ParentReply{
+		ParentID:          parent.ParentID,
+		WhatsappMessageID: message.MessageID,
+		SenderJID:         message.SenderJID,
+		Body:              message.Body,
+		MediaType:         message.MediaType,
+		MediaFileName:     message.MediaFileName,
+		MediaMimeType:     message.MediaMimeType,
+		Media:             message.Media,
+		ReceivedAt:        message.ReceivedAt,
+	}
+	if history != nil {
+		reply.NotificationHistoryID = &history.NotificationHistoryID
+		reply.StudentNSN = &history.StudentNSN
+	}
+
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "whatsapp_message_id"}}, DoNothing: true}).
+		Create(&reply)
+	if result.Error != nil {
+		return nil, fmt.Errorf("could not save reply of parent %d: %w", parent.ParentID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Redelivered after a reconnect, it was handled the first time
+		return nil, nil
+	}
+
+	return &reply, nil
+}
+
+// repliedNotification returns the notification a reply answers: the quoted message when the parent
+// used WhatsApp's reply, otherwise the latest notification sent to the parent. Nil when there is none.
+func (r *parentReplyRepository) repliedNotification(ctx context.Context, parentID int, quotedMessageID *string) (*domain.AttendanceNotificationHistory, error) {
+	var history domain.AttendanceNotificationHistory
+
+	if quotedMessageID != nil {
+		err := r.db.WithContext(ctx).
+			Where("parent_id = ? AND whatsapp_message_id = ?", parentID, *quotedMessageID).
+			Order("notification_history_id DESC").
+			First(&history).Error
+		if err == nil {
+			return &history, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("could not find quoted notification: %w", err)
+		}
+	}
+
+	err := r.db.WithContext(ctx).
+		Where("parent_id = ?", parentID).
+		Order("created_at DESC, notification_history_id DESC").
+		First(&history).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not find latest notification of parent %d: %w", parentID, err)
+	}
+	return &history, nil
+}
+
+func (r *parentReplyRepository) GetAllParentReplies(ctx context.Context, unreadOnly bool) (*[]domain.ParentReply, error) {
+	var replies []domain.ParentReply
+
+	// The media is left out of the list, it is fetched one reply at a time
+	query := r.db.WithContext(ctx).
+		Omit("media").
+		Preload("Parent").
+		Preload("NotificationHistory").
+		Preload("NotificationHistory.Subject")
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Order("received_at DESC").Find(&replies).Error; err != nil {
+		return nil, fmt.Errorf("could not get parent replies: %w", err)
+	}
+	return &replies, nil
+}
+
+func (r *parentReplyRepository) GetParentReplyMedia(ctx context.Context, parentReplyID int) (*domain.ParentReply, error) {
+	var reply domain.ParentReply
+
+	err := r.db.WithContext(ctx).Where("parent_reply_id = ?", parentReplyID).First(&reply).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("parent reply with ID %d not found", parentReplyID)
+		}
+		return nil, fmt.Errorf("could not get parent reply: %w", err)
+	}
+
+	if len(reply.Media) == 0 {
+		return nil, fmt.Errorf("parent reply with ID %d has no media", parentReplyID)
+	}
+	return &reply, nil
+}
+
+func (r *parentReplyRepository) MarkParentReplyRead(ctx context.Context, parentReplyID int, userID int) error {
+	// The first reader is kept when several staff open the same reply
+	result := r.db.WithContext(ctx).Model(&domain.ParentReply{}).
+		Where("parent_reply_id = ?", parentReplyID).
+		Updates(map[string]interface{}{
+			"read_at": gorm.Expr("COALESCE(read_at, ?)", time.Now()),
+			"read_by": gorm.Expr("COALESCE(read_by, ?)", userID),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("could not mark parent reply as read: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("parent reply with ID %d not found", parentReplyID)
+	}
+	return nil
+}
+
+// maxReplyMediaSize caps the photos and documents downloaded from replies, larger ones are
+// recorded without their content.
+const maxReplyMediaSize = 16 << 20
+
+// ListenWhatsAppReplies records the direct messages parents send to the school number, keyword
+// replies (SAKIT, IZIN <days>) are also submitted as excuses for staff to review. The messages are
+// handled one by one in the background, whatsmeow waits for its event handlers before it goes on
+// with receipts and the connection.
+func ListenWhatsAppReplies(client *whatsmeow.Client, replies domain.ParentReplyRepo, excuses domain.ExcusedAbsenceRepo, log *logrus.Logger) {
+	inbound := make(chan *events.Message, 100)
+
+	go func() {
+		for evt := range inbound {
+			handleWhatsAppReply(client, replies, excuses, evt, log)
+		}
+	}()
+
+	client.AddEventHandler(func(rawEvt interface{}) {
+		evt, ok := rawEvt.(*events.Message)
+		if !ok || evt.Info.IsFromMe || evt.Info.IsGroup || evt.Info.Chat.IsBroadcastList() {
+			return
+		}
+		inbound <- evt
+	})
+}
+
+func handleWhatsAppReply(client *whatsmeow.Client, replies domain.ParentReplyRepo, excuses domain.ExcusedAbsenceRepo, evt *events.Message, log *logrus.Logger) {
+	body, mediaType, quotedID := inboundContent(evt.Message)
+	if body == "" && mediaType == nil {
+		// Reactions, receipts of polls and other protocol messages carry nothing to read
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	telephone := senderTelephone(ctx, client, evt.Info.MessageSource)
+	if telephone == "" {
+		log.Infof("Ignoring whatsapp message %s, sender %s has no phone number", evt.Info.ID, evt.Info.Sender)
+		return
+	}
+
+	message := domain.InboundMessage{
+		MessageID:       evt.Info.ID,
+		SenderJID:       evt.Info.Sender.String(),
+		Telephone:       telephone,
+		Body:            body,
+		MediaType:       mediaType,
+		QuotedMessageID: quotedID,
+		ReceivedAt:      evt.Info.Timestamp,
+	}
+	// A reply whose media cannot be fetched is still recorded, staff can ask the parent to send it again
+	if err := downloadInboundMedia(ctx, client, evt.Message, &message); err != nil {
+		log.Warnf("Failed to download media of whatsapp reply %s: %v", evt.Info.ID, err)
+	}
+
+	reply, err := replies.RecordParentReply(ctx, &message)
+	if err != nil {
+		log.Errorf("Failed to record whatsapp reply %s: %v", evt.Info.ID, err)
+		return
+	}
+	if reply == nil {
+		return
+	}
+
+	if _, err := excuses.SubmitParentExcuse(ctx, reply); err != nil {
+		log.Errorf("Failed to submit excuse from whatsapp reply %s: %v", evt.Info.ID, err)
+	}
+}
+
+// downloadInboundMedia fetches the photo or document attached to a message into the inbound
+// message, other media such as voice notes and videos are not kept.
+func downloadInboundMedia(ctx context.Context, client *whatsmeow.Client, msg *waE2E.Message, message *domain.InboundMessage) error {
+	var (
+		media    whatsmeow.DownloadableMessage
+		size     uint64
+		mimeType string
+		fileName string
+	)
+
+	switch {
+	case msg.GetImageMessage() != nil:
+		image := msg.GetImageMessage()
+		media, size, mimeType = image, image.GetFileLength(), image.GetMimetype()
+	case msg.GetDocumentMessage() != nil:
+		document := msg.GetDocumentMessage()
+		media, size, mimeType, fileName = document, document.GetFileLength(), document.GetMimetype(), document.GetFileName()
+	default:
+		return nil
+	}
+
+	if size > maxReplyMediaSize {
+		return fmt.Errorf("media of %d bytes is larger than %d bytes", size, maxReplyMediaSize)
+	}
+
+	data, err := client.Download(ctx, media)
+	if err != nil {
+		return err
+	}
+
+	message.Media = data
+	if mimeType != "" {
+		message.MediaMimeType = &mimeType
+	}
+	if fileName != "" {
+		message.MediaFileName = &fileName
+	}
+	return nil
+}
+
+// inboundContent returns the text of a message (the caption for media), the kind of media
+// attached, if any, and the id of the message it quotes.
+func inboundContent(msg *waE2E.Message) (string, *string, *string) {
+	var (
+		body        string
+		mediaType   string
+		contextInfo *waE2E.ContextInfo
+	)
+
+	switch {
+	case msg.GetConversation() != "":
+		body = msg.GetConversation()
+	case msg.GetExtendedTextMessage() != nil:
+		body = msg.GetExtendedTextMessage().GetText()
+		contextInfo = msg.GetExtendedTextMessage().GetContextInfo()
+	case msg.GetImageMessage() != nil:
+		body = msg.GetImageMessage().GetCaption()
+		mediaType = "image"
+		contextInfo = msg.GetImageMessage().GetContextInfo()
+	case msg.GetDocumentMessage() != nil:
+		body = msg.GetDocumentMessage().GetCaption()
+		if body == "" {
+			body = msg.GetDocumentMessage().GetFileName()
+		}
+		mediaType = "document"
+		contextInfo = msg.GetDocumentMessage().GetContextInfo()
+	case msg.GetAudioMessage() != nil:
+		mediaType = "audio"
+		contextInfo = msg.GetAudioMessage().GetContextInfo()
+	case msg.GetVideoMessage() != nil:
+		body = msg.GetVideoMessage().GetCaption()
+		mediaType = "video"
+		contextInfo = msg.GetVideoMessage().GetContextInfo()
+	}
+
+	var media, quoted *string
+	if mediaType != "" {
+		media = &mediaType
+	}
+	if id := contextInfo.GetStanzaID(); id != "" {
+		quoted = &id
+	}
+	return strings.TrimSpace(body), media, quoted
+}
+
+// senderTelephone returns the sender's phone number (628xx). Newer clients may address messages
+// by a hidden LID, the phone number then comes from the alternative address or the LID store.
+func senderTelephone(ctx context.Context, client *whatsmeow.Client, source types.MessageSource) string {
+	for _, jid := range []types.JID{source.Sender, source.SenderAlt} {
+		if jid.Server == types.DefaultUserServer {
+			return jid.User
+		}
+	}
+
+	if source.Sender.Server == types.HiddenUserServer {
+		pn, err := client.Store.LIDs.GetPNForLID(ctx, source.Sender.ToNonAD())
+		if err == nil && !pn.IsEmpty() {
+			return pn.User
+		}
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"fmt"
+	"notification/domain"
+	"strings"
+	"time"
+)
+
+type reportCardLabels struct {
+	title, telephone, nsn, name, class, parent, issued, no, code, subject, score, average, footer string
+}
+
+var reportCardWording = map[string]reportCardLabels{
+	domain.LanguageEnglish: {
+		title:     "REPORT CARD",
+		telephone: "Tel.",
+		nsn:       "NSN",
+		name:      "Name",
+		class:     "Class",
+		parent:    "Parent",
+		issued:    "Issued",
+		no:        "No",
+		code:      "Code",
+		subject:   "Subject",
+		score:     "Score",
+		average:   "Average",
+		footer:    "This report card was generated automatically, please contact the school for any correction.",
+	},
+	domain.LanguageIndonesian: {
+		title:     "RAPOR HASIL UJIAN",
+		telephone: "Telp.",
+		nsn:       "NSN",
+		name:      "Nama",
+		class:     "Kelas",
+		parent:    "Orang tua",
+		issued:    "Diterbitkan",
+		no:        "No",
+		code:      "Kode",
+		subject:   "Mata pelajaran",
+		score:     "Nilai",
+		average:   "Rata-rata",
+		footer:    "Rapor ini dibuat secara otomatis, silakan hubungi sekolah bila ada kekeliruan.",
+	},
+}
+
+const (
+	reportCardMargin    = 50.0
+	reportCardRowHeight = 18.0
+)
+
+// reportCardFilename names the attachment after the student so parents with several children can tell them apart.
+func reportCardFilename(studentNSN, language string) string {
+	if language == domain.LanguageIndonesian {
+		return fmt.Sprintf("rapor-%s.pdf", studentNSN)
+	}
+	return fmt.Sprintf("report-card-%s.pdf", studentNSN)
+}
+
+// renderReportCard lays out the exam results of one student as a PDF: the school header, the student's
+// details and a table of the subject scores with their average. Long subject lists continue on a new page.
+func renderReportCard(result domain.IndividualExamScore, examType, language, schoolName, schoolPhone string, issued time.Time) ([]byte, error) {
+	labels, ok := reportCardWording[language]
+	if !ok {
+		labels = reportCardWording[domain.LanguageEnglish]
+	}
+
+	doc := newPDFDocument()
+	left, right := reportCardMargin, pdfPageWidth-reportCardMargin
+	y := pdfPageHeight - reportCardMargin
+
+	// School header
+	doc.text(left, y, pdfFontBold, 18, schoolName)
+	y -= 16
+	if schoolPhone != "" {
+		doc.text(left, y, pdfFontRegular, 10, labels.telephone+" "+schoolPhone)
+	}
+	y -= 10
+	doc.line(left, y, right, y, 1.5)
+
+	// Title
+	y -= 30
+	doc.text(left, y, pdfFontBold, 14, labels.title)
+	y -= 18
+	doc.text(left, y, pdfFontRegular, 12, strings.ToUpper(localizeExamType(examType, language)))
+
+	// Student details
+	student := result.Student
+	details := [][2]string{
+		{labels.nsn, student.StudentNSN},
+		{labels.name, student.Name},
+		{labels.class, strings.TrimSpace(fmt.Sprintf("%d %s", student.Grade, student.GradeLabel))},
+		{labels.parent, student.Parent.Name},
+		{labels.issued, issued.Format("02/01/2006")},
+	}
+	y -= 28
+	for _, detail := range details {
+		doc.text(left, y, pdfFontRegular, 11, detail[0])
+		doc.text(left+100, y, pdfFontRegular, 11, ": "+detail[1])
+		y -= 16
+	}
+
+	// Score table
+	colNo, colCode, colSubject, colScore := left+6, left+36, left+126, right-6
+	tableHeader := func() {
+		doc.fillRect(left, y-6, right-left, reportCardRowHeight+2, 0.85)
+		doc.text(colNo, y, pdfFontBold, 10, labels.no)
+		doc.text(colCode, y, pdfFontBold, 10, labels.code)
+		doc.text(colSubject, y, pdfFontBold, 10, labels.subject)
+		doc.textRight(colScore, y, pdfFontBold, 10, labels.score)
+		y -= reportCardRowHeight + 2
+	}
+
+	y -= 14
+	tableHeader()
+
+	var total float64
+	var scored int
+	for i, row := range result.SubjectAndScoreResult {
+		if y < reportCardMargin+2*reportCardRowHeight {
+			doc.addPage()
+			y = pdfPageHeight - reportCardMargin
+			tableHeader()
+		}
+
+		score := "-"
+		if row.Score != nil {
+			score = fmt.Sprintf("%.1f", *row.Score)
+			total += *row.Score
+			scored++
+		}
+
+		doc.text(colNo, y, pdfFontRegular, 10, fmt.Sprintf("%d", i+1))
+		doc.text(colCode, y, pdfFontRegular, 10, row.Subject.SubjectCode)
+		doc.text(colSubject, y, pdfFontRegular, 10, row.Subject.Name)
+		doc.textRight(colScore, y, pdfFontRegular, 10, score)
+		doc.line(left, y-6, right, y-6, 0.5)
+		y -= reportCardRowHeight
+	}
+
+	average := "-"
+	if scored > 0 {
+		average = fmt.Sprintf("%.1f", total/float64(scored))
+	}
+	doc.text(colSubject, y, pdfFontBold, 10, labels.average)
+	doc.textRight(colScore, y, pdfFontBold, 10, average)
+	doc.line(left, y-6, right, y-6, 1)
+
+	doc.text(left, reportCardMargin-20, pdfFontRegular, 8, labels.footer)
+
+	return doc.bytes()
+}
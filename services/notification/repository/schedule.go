@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notification/domain"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type scheduledNotificationRepository struct {
+	db     *gorm.DB
+	sender domain.SenderRepo
+	lease  time.Duration
+}
+
+// NewScheduledNotificationRepository creates the scheduler, lease is how long a claimed schedule stays
+// locked before it is picked up again, e.g. after the server was restarted in the middle of a run.
+func NewScheduledNotificationRepository(db *gorm.DB, sender domain.SenderRepo, lease time.Duration) domain.ScheduledNotificationRepo {
+	return &scheduledNotificationRepository{
+		db:     db,
+		sender: sender,
+		lease:  lease,
+	}
+}
+
+func (s *scheduledNotificationRepository) GetAllScheduledNotifications(ctx context.Context, status string) (*[]domain.ScheduledNotification, error) {
+	var schedules []domain.ScheduledNotification
+
+	query := s.db.WithContext(ctx)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.Order("run_at DESC").Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get scheduled notifications: %w", err)
+	}
+
+	return &schedules, nil
+}
+
+func (s *scheduledNotificationRepository) CreateScheduledNotification(ctx context.Context, payload *domain.ScheduledNotificationPayload, userID int) (*domain.ScheduledNotification, error) {
+	setting, err := loadSchoolSetting(s.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	runAt, err := time.ParseInLocation("2006-01-02 15:04", payload.RunAt, schoolNow(setting).Location())
+	if err != nil {
+		return nil, fmt.Errorf("invalid run at %s, expected format YYYY-MM-DD HH:MM", payload.RunAt)
+	}
+	if !runAt.After(time.Now()) {
+		return nil, fmt.Errorf("run at %s is in the past", payload.RunAt)
+	}
+
+	schedule := domain.ScheduledNotification{
+		EventType: payload.EventType,
+		RunAt:     runAt,
+		UserID:    userID,
+		Status:    domain.ScheduleStatusPending,
+	}
+
+	switch payload.EventType {
+	case domain.EventAbsence:
+		if len(payload.NSNList) == 0 {
+			return nil, fmt.Errorf("nsn list is required for an absence notification")
+		}
+
+		var subjectCount int64
+		err = s.db.WithContext(ctx).Model(&domain.Subject{}).Where("subject_code = ?", payload.SubjectCode).Count(&subjectCount).Error
+		if err != nil {
+			return nil, fmt.Errorf("error checking subject: %w", err)
+		}
+		if subjectCount == 0 {
+			return nil, fmt.Errorf("subject with code %s not found", payload.SubjectCode)
+		}
+
+		// Dates are kept at UTC midnight of the school's calendar day, like recorded absences
+		absenceDate := time.Date(runAt.Year(), runAt.Month(), runAt.Day(), 0, 0, 0, 0, time.UTC)
+		schedule.NSNList = payload.NSNList
+		schedule.SubjectCode = &payload.SubjectCode
+		schedule.AbsenceDate = &absenceDate
+	case domain.EventExamResult:
+		examType := strings.TrimSpace(payload.ExamType)
+		if examType == "" {
+			return nil, fmt.Errorf("exam type is required for an exam result notification")
+		}
+		schedule.ExamType = &examType
+		schedule.ReportCard = payload.ReportCard
+	default:
+		return nil, fmt.Errorf("event type %s cannot be scheduled", payload.EventType)
+	}
+
+	if err := s.db.WithContext(ctx).Create(&schedule).Error; err != nil {
+		return nil, fmt.Errorf("could not create scheduled notification: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+// CancelScheduledNotification cancels a schedule that has not started running yet.
+func (s *scheduledNotificationRepository) CancelScheduledNotification(ctx context.Context, scheduledNotificationID int) error {
+	result := s.db.WithContext(ctx).Model(&domain.ScheduledNotification{}).
+		Where("scheduled_notification_id = ? AND status = ?", scheduledNotificationID, domain.ScheduleStatusPending).
+		Updates(map[string]interface{}{
+			"status":     domain.ScheduleStatusCancelled,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("could not cancel scheduled notification: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("pending scheduled notification with ID %d not found", scheduledNotificationID)
+	}
+
+	return nil
+}
+
+// RunNext runs the oldest due schedule, it reports false when nothing was due.
+func (s *scheduledNotificationRepository) RunNext(ctx context.Context) (bool, error) {
+	schedule, err := s.claimNext(ctx)
+	if err != nil {
+		return false, err
+	}
+	if schedule == nil {
+		return false, nil
+	}
+
+	// The key makes a rerun after a crash return the job of the interrupted run
+	idempotencyKey := fmt.Sprintf("scheduled-%d", schedule.ScheduledNotificationID)
+
+	var jobID *string
+	var runErr error
+	switch schedule.EventType {
+	case domain.EventAbsence:
+		var job *domain.NotificationJob
+		job, runErr = s.sender.SendMass(ctx, &schedule.NSNList, &schedule.UserID, *schedule.SubjectCode, schedule.AbsenceDate, &idempotencyKey)
+		if job != nil {
+			jobID = &job.JobID
+		}
+	case domain.EventExamResult:
+		var job *domain.NotificationJob
+		job, runErr = s.sender.SendTestScores(ctx, *schedule.ExamType, schedule.ReportCard, &schedule.UserID, &idempotencyKey)
+		if job != nil {
+			jobID = &job.JobID
+		}
+	default:
+		runErr = fmt.Errorf("event type %s cannot be scheduled", schedule.EventType)
+	}
+
+	return true, s.complete(ctx, schedule, jobID, runErr)
+}
+
+// claimNext locks the oldest due pending schedule (or one whose lease expired) so that
+// a schedule is never run by two workers at the same time.
+func (s *scheduledNotificationRepository) claimNext(ctx context.Context) (*domain.ScheduledNotification, error) {
+	var schedule domain.ScheduledNotification
+	now := time.Now()
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)",
+				domain.ScheduleStatusPending, now, domain.ScheduleStatusRunning, now).
+			Order("run_at").
+			First(&schedule).Error
+		if err != nil {
+			return err
+		}
+
+		lockedUntil := now.Add(s.lease)
+		schedule.Status = domain.ScheduleStatusRunning
+		schedule.LockedUntil = &lockedUntil
+
+		return tx.Model(&domain.ScheduledNotification{}).
+			Where("scheduled_notification_id = ?", schedule.ScheduledNotificationID).
+			Updates(map[string]interface{}{
+				"status":       domain.ScheduleStatusRunning,
+				"locked_until": lockedUntil,
+			}).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim scheduled notification: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+func (s *scheduledNotificationRepository) complete(ctx context.Context, schedule *domain.ScheduledNotification, jobID *string, runErr error) error {
+	fields := map[string]interface{}{
+		"status":       domain.ScheduleStatusDone,
+		"job_id":       jobID,
+		"locked_until": nil,
+		"executed_at":  time.Now(),
+		"last_error":   nil,
+	}
+	if runErr != nil {
+		fields["status"] = domain.ScheduleStatusFailed
+		fields["last_error"] = runErr.Error()
+	}
+
+	err := s.db.WithContext(ctx).Model(&domain.ScheduledNotification{}).
+		Where("scheduled_notification_id = ?", schedule.ScheduledNotificationID).
+		Updates(fields).Error
+	if err != nil {
+		return fmt.Errorf("failed to complete scheduled notification %d: %w", schedule.ScheduledNotificationID, err)
+	}
+
+	if runErr != nil {
+		return fmt.Errorf("scheduled notification %d failed: %w", schedule.ScheduledNotificationID, runErr)
+	}
+	return nil
+}
@@ -2,212 +2,70 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/smtp"
 	"notification/domain"
 	"os"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	"go.mau.fi/whatsmeow"
-	"go.mau.fi/whatsmeow/proto/waE2E"
-	"go.mau.fi/whatsmeow/types"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // init var
 type senderRepository struct {
 	db          *gorm.DB
-	client      smtp.Auth
-	emailSender string
+	notifiers   domain.NotifierRegistry
+	schoolName  string
 	schoolPhone string
-	smtpAdress  string
-	meowClient  *whatsmeow.Client
+	dedupWindow time.Duration
+	adminEmail  *string
 }
 
-func NewSenderRepository(db *gorm.DB, client smtp.Auth, smtpAddress, schoolPhone, emailSender string, meow *whatsmeow.Client) domain.SenderRepo {
+func NewSenderRepository(db *gorm.DB, notifiers domain.NotifierRegistry, schoolName, schoolPhone string, dedupWindow time.Duration, adminEmail *string) domain.SenderRepo {
 	return &senderRepository{
 		db:          db,
-		client:      client,
-		emailSender: emailSender,
+		notifiers:   notifiers,
+		schoolName:  schoolName,
 		schoolPhone: schoolPhone,
-		smtpAdress:  smtpAddress,
-		meowClient:  meow,
+		dedupWindow: dedupWindow,
+		adminEmail:  adminEmail,
 	}
 }
 
-func (m *senderRepository) createTestScoreEmail(individual domain.IndividualExamScore, examType string) string {
-	// Start with a general introduction
-	if individual.Student.Parent.Gender != "male" {
-		body := fmt.Sprintf(`SINOAN Service 🔔
-
-Dear Mrs. %s,
-We would like to inform you about the %s results for the following student:
-NSN: %s,
-Name: %s,
-Class: %s.
-Below are the details of the test results for several subjects:
-`, individual.Student.Parent.Name, examType, individual.Student.StudentNSN, individual.Student.Name, fmt.Sprintf("%d %s", individual.Student.Grade, individual.Student.GradeLabel))
-
-		// Add the subject and score details
-		for _, result := range individual.SubjectAndScoreResult {
-			subjectName := result.Subject.Name
-			score := "No Score Yet | 0"
-			if result.Score != nil {
-				score = fmt.Sprintf("%.1f", *result.Score)
-			}
-
-			body += fmt.Sprintf("- Code (%s) | Subject: %s | Score: %s\n", result.Subject.SubjectCode, subjectName, score)
-		}
-
-		// Close the email with contact details
-		body += fmt.Sprintf(`
-If you have any questions or need further information, you can contact us at %s.
-
-Thank you for your attention and cooperation.
-
-Sincerely,
-SINOAN Team`, m.schoolPhone)
-
-		bodyTestScore := body
-		return bodyTestScore
-	} else {
-		body := fmt.Sprintf(`SINOAN Service 🔔
-
-Dear Mr. %s,
-We would like to inform you about the %s results for the following student:
-NSN: %s,
-Name: %s,
-Class: %s.
-Below are the details of the test results for several subjects:
-`, individual.Student.Parent.Name, examType, individual.Student.StudentNSN, individual.Student.Name, fmt.Sprintf("%d %s", individual.Student.Grade, individual.Student.GradeLabel))
-
-		// Add the subject and score details
-		for _, result := range individual.SubjectAndScoreResult {
-			subjectName := result.Subject.Name
-			score := "No Score Yet | 0"
-			if result.Score != nil {
-				score = fmt.Sprintf("%.1f", *result.Score)
-			}
-
-			body += fmt.Sprintf("- Code (%s) | Subject: %s | Score: %s\n", result.Subject.SubjectCode, subjectName, score)
-		}
-
-		// Close the email with contact details
-		body += fmt.Sprintf(`
-If you have any questions or need further information, you can contact us at %s.
-
-Thank you for your attention and cooperation.
-
-Sincerely,
-SINOAN Team`, m.schoolPhone)
-
-		bodyTestScore := body
-		return bodyTestScore
+// localizeExamType names the exam type in the given language
+func localizeExamType(examType, language string) string {
+	if language != domain.LanguageIndonesian {
+		return examType
 	}
 
-}
-
-func (m *senderRepository) buatNilaiTesEmail(individual domain.IndividualExamScore, examType string) string {
-	// Mulai dengan pengantar umum
-	if individual.Student.Parent.Gender != "male" {
-		body := fmt.Sprintf(`Layanan SINOAN 🔔
-
-Yth. Ibu %s,
-Kami ingin memberitahukan tentang hasil %s untuk siswa berikut:
-NSN: %s,
-Nama: %s,
-Kelas: %s.
-Berikut adalah detail hasil ujian untuk beberapa mata pelajaran:
-`, individual.Student.Parent.Name, examType, individual.Student.StudentNSN, individual.Student.Name, fmt.Sprintf("%d %s", individual.Student.Grade, individual.Student.GradeLabel))
-
-		// Tambahkan detail mata pelajaran dan nilai
-		for _, result := range individual.SubjectAndScoreResult {
-			subjectName := result.Subject.Name
-			score := "Belum Ada Nilai | 0"
-			if result.Score != nil {
-				score = fmt.Sprintf("%.1f", *result.Score)
-			}
-
-			body += fmt.Sprintf("- Kode (%s) | Mata Pelajaran: %s | Nilai: %s\n", result.Subject.SubjectCode, subjectName, score)
-		}
-
-		// Tutup email dengan detail kontak
-		body += fmt.Sprintf(`
-Jika ibu memiliki pertanyaan atau membutuhkan informasi lebih lanjut, ibu dapat menghubungi kami di %s.
-
-Terima kasih atas perhatian dan kerjasamanya.
-
-Hormat kami,
-Tim SINOAN`, m.schoolPhone)
-
-		bodyTestScore := body
-		return bodyTestScore
-	} else {
-		body := fmt.Sprintf(`Layanan SINOAN 🔔
-
-Yth. Bapak %s,
-Kami ingin memberitahukan tentang hasil %s untuk siswa berikut:
-NSN: %s,
-Nama: %s,
-Kelas: %s.
-Berikut adalah detail hasil ujian untuk beberapa mata pelajaran:
-`, individual.Student.Parent.Name, examType, individual.Student.StudentNSN, individual.Student.Name, fmt.Sprintf("%d %s", individual.Student.Grade, individual.Student.GradeLabel))
-
-		// Tambahkan detail mata pelajaran dan nilai
-		for _, result := range individual.SubjectAndScoreResult {
-			subjectName := result.Subject.Name
-			score := "Belum Ada Nilai | 0"
-			if result.Score != nil {
-				score = fmt.Sprintf("%.1f", *result.Score)
-			}
-
-			body += fmt.Sprintf("- Kode (%s) | Mata Pelajaran: %s | Nilai: %s\n", result.Subject.SubjectCode, subjectName, score)
-		}
-
-		// Tutup email dengan detail kontak
-		body += fmt.Sprintf(`
-Jika bapak memiliki pertanyaan atau membutuhkan informasi lebih lanjut, bapak dapat menghubungi kami di %s.
-
-Terima kasih atas perhatian dan kerjasamanya.
-
-Hormat kami,
-Tim SINOAN`, m.schoolPhone)
-
-		bodyTestScore := body
-		return bodyTestScore
+	switch examType {
+	case "Midterm Tests":
+		return "Ulangan Tengah Semester (UTS)"
+	case "End of Semester Tests":
+		return "Ulangan Akhir Semester (UAS)"
+	default:
+		return examType
 	}
 }
 
-func (m *senderRepository) SendTestScores(ctx context.Context, examType string) error {
+// collectExamResults groups the unsent test scores per student, it only reads so previews can share it.
+// forUpdate locks the scores until the caller's transaction marks them as sent.
+func collectExamResults(db *gorm.DB, forUpdate bool) ([]domain.IndividualExamScore, []string, error) {
 	var testScores []domain.TestScore
 	var students []domain.Student
 	var resultsMap = make(map[string]domain.IndividualExamScore)
-	langValue := os.Getenv("MESSENGER_LANGUAGE")
-	langValueLowered := strings.ToLower(langValue)
-	var examTypeProcessed string
-	fmt.Println(examType)
-
-	// Process exam type based on language
-	if langValueLowered == "ind" {
-		switch examType {
-		case "Midterm Tests":
-			examTypeProcessed = "Ulangan Tengah Semester (UTS)"
-		case "End of Semester Tests":
-			examTypeProcessed = "Ulangan Akhir Semester (UAS)"
-		default:
-			examTypeProcessed = examType
-		}
-	} else {
-		examTypeProcessed = examType
-	}
+	var skipped []string
 
-	fmt.Println(examTypeProcessed)
+	query := db
+	if forUpdate {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}})
+	}
 
 	// Fetch all test scores with related data
-	err := m.db.WithContext(ctx).
+	err := query.
 		Preload("Student").
 		Preload("Subject").
 		Preload("User", func(db *gorm.DB) *gorm.DB {
@@ -216,11 +74,11 @@ func (m *senderRepository) SendTestScores(ctx context.Context, examType string)
 		Where("sent_at IS NULL").
 		Find(&testScores).Error
 	if err != nil {
-		return fmt.Errorf("failed to fetch test scores: %w", err)
+		return nil, nil, fmt.Errorf("failed to fetch test scores: %w", err)
 	}
 
 	if len(testScores) == 0 {
-		return fmt.Errorf("theres no any test scores to be sent")
+		return nil, nil, fmt.Errorf("theres no any test scores to be sent")
 	}
 
 	// Extract student IDs from test scores
@@ -230,12 +88,12 @@ func (m *senderRepository) SendTestScores(ctx context.Context, examType string)
 	}
 
 	// Fetch all students associated with the test scores
-	err = m.db.WithContext(ctx).
+	err = db.
 		Preload("Parent").
 		Where("student_nsn IN (?)", studentIDs).
 		Find(&students).Error
 	if err != nil {
-		return fmt.Errorf("failed to fetch students: %w", err)
+		return nil, nil, fmt.Errorf("failed to fetch students: %w", err)
 	}
 
 	// Build a map of students for quick lookup
@@ -248,6 +106,7 @@ func (m *senderRepository) SendTestScores(ctx context.Context, examType string)
 	for _, score := range testScores {
 		student, exists := studentMap[score.StudentNSN]
 		if !exists {
+			skipped = append(skipped, fmt.Sprintf("student with StudentNSN %s not found", score.StudentNSN))
 			continue
 		}
 
@@ -260,6 +119,8 @@ func (m *senderRepository) SendTestScores(ctx context.Context, examType string)
 			}
 		}
 
+		individual.TestScoreIDs = append(individual.TestScoreIDs, score.TestScoreID)
+
 		// Prevent duplicate subjects
 		duplicate := false
 		for _, subject := range individual.SubjectAndScoreResult {
@@ -285,389 +146,1101 @@ func (m *senderRepository) SendTestScores(ctx context.Context, examType string)
 		results = append(results, result)
 	}
 
-	// Worker pool to limit concurrency
-	const maxWorkers = 10 // Adjust based on system capacity
-	var wg sync.WaitGroup
-	workerPool := make(chan struct{}, maxWorkers)
-	errChan := make(chan error, len(results)) // Channel to collect errors
+	return results, skipped, nil
+}
 
-	// Process results concurrently
-	for _, idv := range results {
-		wg.Add(1)
-		workerPool <- struct{}{} // Acquire a worker slot
+// SendTestScores queues the unsent test scores, a request repeated with the same idempotency key
+// returns the job of the first one. With reportCard every message carries the student's PDF report card.
+func (m *senderRepository) SendTestScores(ctx context.Context, examType string, reportCard bool, userID *int, idempotencyKey *string) (*domain.NotificationJob, error) {
+	if idempotencyKey != nil {
+		if job, err := m.findJobByIdempotencyKey(ctx, *idempotencyKey, domain.EventExamResult); err != nil || job != nil {
+			return job, err
+		}
+	}
 
-		go func(idv domain.IndividualExamScore) {
-			defer wg.Done()
-			defer func() { <-workerPool }() // Release the worker slot
+	language := messengerLanguage()
+	examTypeProcessed := localizeExamType(examType, language)
 
-			var messageString string
-			if langValueLowered == "ind" {
-				messageString = m.buatNilaiTesEmail(idv, examTypeProcessed)
-			} else {
-				messageString = m.createTestScoreEmail(idv, examTypeProcessed)
+	job := domain.NotificationJob{
+		JobID:          uuid.NewString(),
+		EventType:      domain.EventExamResult,
+		IdempotencyKey: idempotencyKey,
+		UserID:         userID,
+	}
+
+	// Queue the messages and mark the scores as announced in one transaction,
+	// the outbox workers take care of the actual delivery.
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		results, skipped, err := collectExamResults(tx, true)
+		if err != nil {
+			return err
+		}
+
+		templates, err := loadTemplateSet(tx, domain.EventExamResult)
+		if err != nil {
+			return err
+		}
+
+		// Only the scores that were actually queued are marked, the others are announced once the parent can be reached
+		var announced []int
+
+		for _, idv := range results {
+			if idv.Student.Parent.OptedOut(domain.EventExamResult) {
+				skipped = append(skipped, optedOutMessage(idv.StudentNSN, domain.EventExamResult))
+				continue
 			}
 
-			// Send email
-			if idv.Student.Parent.Email != nil && *idv.Student.Parent.Email != "" {
-				if err := m.sendEmailTestScore(&idv, messageString); err != nil {
-					errChan <- fmt.Errorf("failed to send email to %s: %w", *idv.Student.Parent.Email, err)
-					return
-				}
+			data := m.examResultTemplateData(idv, examType)
+			msgs, err := m.buildOutboxMessages(job.JobID, domain.EventExamResult, templates, language, data, nil)
+			if err != nil {
+				return fmt.Errorf("failed to render test score for student %s: %w", idv.StudentNSN, err)
+			}
+			if len(msgs) == 0 {
+				skipped = append(skipped, fmt.Sprintf("parent of student %s has no reachable channel", idv.StudentNSN))
+				continue
 			}
 
-			// Send WhatsApp
-			if err := m.sendWATestScore(ctx, &idv, messageString); err != nil {
-				errChan <- fmt.Errorf("failed to send WhatsApp for student %s: %w", idv.StudentNSN, err)
-				return
+			if reportCard {
+				if err := m.attachReportCard(msgs, idv, examType, language); err != nil {
+					return err
+				}
 			}
-		}(idv)
-	}
 
-	// Wait for all goroutines to finish
-	wg.Wait()
-	close(errChan) // Close the error channel
+			if err := tx.Create(&msgs).Error; err != nil {
+				return fmt.Errorf("failed to queue test score for student %s: %w", idv.StudentNSN, err)
+			}
+			job.TotalRecipients++
+			job.TotalMessages += len(msgs)
+			announced = append(announced, idv.TestScoreIDs...)
+		}
 
-	// Collect errors from the error channel
-	var errors []error
-	for err := range errChan {
-		errors = append(errors, err)
-	}
+		job.Skipped = skipped
+		if err := tx.Create(&job).Error; err != nil {
+			return fmt.Errorf("failed to create notification job: %w", err)
+		}
 
-	// Log all errors
-	if len(errors) > 0 {
-		for _, err := range errors {
-			fmt.Println("Error:", err)
+		if len(announced) == 0 {
+			return nil
 		}
-		return fmt.Errorf("encountered %d errors while sending test scores", len(errors))
-	}
 
-	// Mark test scores as deleted
-	err = m.db.WithContext(ctx).
-		Model(&domain.TestScore{}).
-		Where("sent_at IS NULL").
-		Updates(map[string]interface{}{
-			"sent_at": time.Now(),
-			"type":    examTypeProcessed,
-		}).Error
+		// Mark test scores as sent
+		err = tx.Model(&domain.TestScore{}).
+			Where("test_score_id IN (?)", announced).
+			Updates(map[string]interface{}{
+				"sent_at": time.Now(),
+				"type":    examTypeProcessed,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to mark test scores as sent: %w", err)
+		}
+		return nil
+	})
 
 	if err != nil {
-		return fmt.Errorf("failed to soft delete all test scores: %w", err)
+		if idempotencyKey != nil {
+			if existing, findErr := m.findJobByIdempotencyKey(ctx, *idempotencyKey, domain.EventExamResult); findErr == nil && existing != nil {
+				return existing, nil
+			}
+		}
+		return nil, err
 	}
 
-	return nil
+	return &job, nil
 }
 
-func (m *senderRepository) SendMass(ctx context.Context, nsnList *[]string, userID *int, subjectCode string) error {
+// SendMass records the listed students as absent from the lesson on date (today when nil) and notifies
+// their parents, it is kept for callers that do not submit a full roll call. A request repeated with the
+// same idempotency key returns the job of the first one instead of sending again.
+func (m *senderRepository) SendMass(ctx context.Context, nsnList *[]string, userID *int, subjectCode string, date *time.Time, idempotencyKey *string) (*domain.NotificationJob, error) {
+	if idempotencyKey != nil {
+		if job, err := m.findJobByIdempotencyKey(ctx, *idempotencyKey, domain.EventAbsence); err != nil || job != nil {
+			return job, err
+		}
+	}
+
 	// Fetch the subject details
-	langValue := os.Getenv("MESSENGER_LANGUAGE")
-	langValueLowered := strings.ToLower(langValue)
 	var subject domain.Subject
 	err := m.db.WithContext(ctx).Where("subject_code = ?", subjectCode).First(&subject).Error
 	if err != nil {
-		return fmt.Errorf("failed to fetch subject details: %v", err)
+		return nil, fmt.Errorf("failed to fetch subject details: %v", err)
 	}
 
-	for _, nsn := range *nsnList {
-		var waStatus, emailStatus bool
-		waStatus, emailStatus = false, false
-
-		// Fetch student and parent details
-		student, err := m.fetchStudentDetails(ctx, nsn)
+	var job *domain.NotificationJob
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attendances, skipped, err := recordAbsences(tx, *nsnList, subjectCode, *userID, date)
 		if err != nil {
-			continue // Skip the current student if details cannot be fetched
+			return err
 		}
 
-		var subjectForEmailSender *string
-		var body *string
-		if langValueLowered == "ind" {
-			// Initialize notification text with subject name
-			subjectForEmailSender, body, err = m.inisialisasiTeksDenganSubjek(student, subject.Name)
-			if err != nil {
-				return err
-			}
-		} else {
-			// Initialize notification text with subject name
-			subjectForEmailSender, body, err = m.initTextWithSubject(student, subject.Name)
-			if err != nil {
-				return err
+		job, err = m.queueAbsences(tx, attendances, userID, skipped, idempotencyKey)
+		return err
+	})
+
+	if err != nil {
+		// A concurrent request with the same key won the race, hand back its job
+		if idempotencyKey != nil {
+			if existing, findErr := m.findJobByIdempotencyKey(ctx, *idempotencyKey, domain.EventAbsence); findErr == nil && existing != nil {
+				return existing, nil
 			}
 		}
+		return nil, err
+	}
 
-		// Attempt to send an email notification
-		if student.Parent.Email != nil && *student.Parent.Email != "" {
-			if err := m.sendEmail(student, *subjectForEmailSender, *body); err != nil {
-				fmt.Printf("Failed to send email to: %s\n", *student.Parent.Email)
-				continue
-			}
-			emailStatus = true
+	return job, nil
+}
+
+// findJobByIdempotencyKey returns the job created under the key by a send of the same event, nil when there is none.
+// A key reused for another event does not match, creating its job then fails on the unique key.
+func (m *senderRepository) findJobByIdempotencyKey(ctx context.Context, idempotencyKey, eventType string) (*domain.NotificationJob, error) {
+	var job domain.NotificationJob
+	err := m.db.WithContext(ctx).Where("idempotency_key = ? AND event_type = ?", idempotencyKey, eventType).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("could not look up idempotency key: %w", err)
+	}
+	return &job, nil
+}
 
-		// Attempt to send a WhatsApp notification
-		err = m.sendWA(ctx, student, *body)
+// SendRollCall stores the register and queues the notices of the absences not notified yet in the same
+// transaction, so a register is never left stored with absences nobody is going to notify.
+func (m *senderRepository) SendRollCall(ctx context.Context, rollCall *domain.RollCall, userID *int) (*domain.RollCallResult, error) {
+	var result domain.RollCallResult
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attendances, err := recordRollCall(tx, rollCall, *userID)
 		if err != nil {
-			fmt.Printf("Failed to send WhatsApp message to: %s\n", student.Parent.Telephone)
-			continue
+			return err
 		}
-		waStatus = true
+		result.Attendances = attendances
 
-		// Log the notification history
-		err = m.logNotificationHistory(student.Student.StudentNSN, subjectCode, student.Student.ParentID, *userID, waStatus, emailStatus)
-		if err != nil {
-			return fmt.Errorf("failed saving the data to notification history, error: %v", err)
+		var absences []domain.Attendance
+		for _, attendance := range attendances {
+			if attendance.Status == domain.AttendanceAbsent && attendance.NotifiedAt == nil {
+				absences = append(absences, attendance)
+			}
 		}
+
+		if len(absences) == 0 {
+			return nil
+		}
+
+		result.Job, err = m.queueAbsences(tx, absences, userID, nil, nil)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &result, nil
 }
 
-func (m *senderRepository) fetchStudentDetails(ctx context.Context, nsn string) (*domain.StudentAndParent, error) {
-	var student domain.Student
-	var parent domain.Parent
+// recordAbsences upserts the unnumbered session of date (today when nil) as absent for every known student in the list.
+func recordAbsences(tx *gorm.DB, nsnList []string, subjectCode string, userID int, date *time.Time) ([]domain.Attendance, []string, error) {
+	var skipped []string
 
-	err := m.db.WithContext(ctx).Where("student_nsn = ?", nsn).Preload("Parent").First(&student).Error
+	var students []domain.Student
+	if err := tx.Where("student_nsn IN (?)", nsnList).Find(&students).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch students: %w", err)
+	}
+
+	known := make(map[string]bool, len(students))
+	for _, student := range students {
+		known[student.StudentNSN] = true
+	}
+
+	setting, err := loadSchoolSetting(tx)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("student with StudentNSN %s not found", nsn)
+		return nil, nil, err
+	}
+
+	// Dates are kept at UTC midnight of the school's calendar day, like the parsed roll call dates
+	tNow := schoolNow(setting)
+	if date != nil {
+		tNow = *date
+	}
+	today := time.Date(tNow.Year(), tNow.Month(), tNow.Day(), 0, 0, 0, 0, time.UTC)
+
+	var records []domain.Attendance
+	var recordedNSN []string
+	for _, nsn := range nsnList {
+		if !known[nsn] {
+			skipped = append(skipped, fmt.Sprintf("student with StudentNSN %s not found", nsn))
+			continue
 		}
-		return nil, fmt.Errorf("could not fetch student details: %v", err)
+
+		records = append(records, domain.Attendance{
+			StudentNSN:  nsn,
+			SubjectCode: subjectCode,
+			Date:        today,
+			Status:      domain.AttendanceAbsent,
+			UserID:      userID,
+		})
+		recordedNSN = append(recordedNSN, nsn)
 	}
 
-	err = m.db.WithContext(ctx).Where("parent_id = ? AND deleted_at IS NULL", student.ParentID).First(&parent).Error
+	if len(records) == 0 {
+		return nil, skipped, nil
+	}
+
+	if err := upsertAttendances(tx, records); err != nil {
+		return nil, nil, err
+	}
+
+	var attendances []domain.Attendance
+	err = tx.Preload("Subject").
+		Where("student_nsn IN (?) AND subject_code = ? AND date = ? AND session = 0", recordedNSN, subjectCode, today.Format("2006-01-02")).
+		Find(&attendances).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("parent with ID %d not found", student.ParentID)
+		return nil, nil, fmt.Errorf("failed to fetch recorded absences: %w", err)
+	}
+
+	var pending []domain.Attendance
+	for _, attendance := range attendances {
+		if attendance.NotifiedAt != nil {
+			skipped = append(skipped, fmt.Sprintf("parent of student %s was already notified of this absence", attendance.StudentNSN))
+			continue
 		}
-		return nil, fmt.Errorf("could not fetch parent details: %v", err)
+		pending = append(pending, attendance)
 	}
 
-	return &domain.StudentAndParent{
-		Student: student,
-		Parent:  parent,
-	}, nil
+	return pending, skipped, nil
 }
 
-func (m *senderRepository) sendEmail(payload *domain.StudentAndParent, subjectEmail string, body string) error {
-	msg := "From: " + m.emailSender + "\r\n" +
-		"To: " + *payload.Parent.Email + "\r\n" +
-		"Subject: " + subjectEmail + "\r\n" +
-		"MIME-Version: 1.0\r\n" +
-		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
-		body
+// queueAbsences renders and queues one notification per absence and marks the absence as notified,
+// absences whose parent already got a notice for the subject within the dedup window are left out.
+// In digest mode today's absences are left for the daily digest, no job is created when nothing is left.
+func (m *senderRepository) queueAbsences(tx *gorm.DB, attendances []domain.Attendance, userID *int, skipped []string, idempotencyKey *string) (*domain.NotificationJob, error) {
+	setting, err := loadSchoolSetting(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := holdForDigest(tx, setting)
+	if err != nil {
+		return nil, err
+	}
+
+	if hold {
+		today := schoolNow(setting).Format("2006-01-02")
+		var immediate []domain.Attendance
+		for _, attendance := range attendances {
+			if attendance.Date.Format("2006-01-02") != today {
+				immediate = append(immediate, attendance)
+			}
+		}
 
-	err := smtp.SendMail(m.smtpAdress, m.client, m.emailSender, []string{*payload.Parent.Email}, []byte(msg))
+		if len(immediate) == 0 {
+			return nil, nil
+		}
+		attendances = immediate
+	}
 
+	language := messengerLanguage()
+	job := domain.NotificationJob{
+		JobID:          uuid.NewString(),
+		EventType:      domain.EventAbsence,
+		IdempotencyKey: idempotencyKey,
+		UserID:         userID,
+	}
+	var duplicates, notified []string
+
+	templates, err := loadTemplateSet(tx, domain.EventAbsence)
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return nil, err
 	}
-	return nil
+
+	for _, attendance := range attendances {
+		nsn := attendance.StudentNSN
+
+		// Fetch student and parent details
+		student, err := fetchStudentDetails(tx, nsn)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			continue // Skip the current student if details cannot be fetched
+		}
+
+		if student.Parent.OptedOut(domain.EventAbsence) {
+			skipped = append(skipped, optedOutMessage(nsn, domain.EventAbsence))
+			continue
+		}
+
+		excused, err := isExcused(tx, nsn, attendance.Date)
+		if err != nil {
+			return nil, err
+		}
+		if excused {
+			skipped = append(skipped, excusedMessage(nsn, attendance.Date))
+			continue
+		}
+
+		duplicate, err := m.alreadyNotified(tx, attendance)
+		if err != nil {
+			return nil, err
+		}
+		if duplicate {
+			duplicates = append(duplicates, nsn)
+			continue
+		}
+
+		data := m.absenceTemplateData(student, attendance.Subject)
+		data.Date = attendance.Date.Format("02/01/2006")
+		data.Session = attendance.Session
+
+		msgs, err := m.buildOutboxMessages(job.JobID, domain.EventAbsence, templates, language, data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render notification for student %s: %w", nsn, err)
+		}
+		if len(msgs) == 0 {
+			skipped = append(skipped, fmt.Sprintf("parent of student %s has no reachable channel", nsn))
+			continue
+		}
+
+		// History is only logged for a notice that is actually queued, it counts for dedup and escalations.
+		// The statuses are flipped by the outbox once delivered
+		history, err := logNotificationHistory(tx, nsn, attendance.SubjectCode, student.Student.ParentID, *userID, &attendance.AttendanceID, job.JobID)
+		if err != nil {
+			return nil, fmt.Errorf("failed saving the data to notification history, error: %v", err)
+		}
+		for i := range msgs {
+			msgs[i].NotificationHistoryID = &history.NotificationHistoryID
+		}
+
+		if err := tx.Create(&msgs).Error; err != nil {
+			return nil, fmt.Errorf("failed to queue notification for student %s: %w", nsn, err)
+		}
+
+		err = tx.Model(&domain.Attendance{}).
+			Where("attendance_id = ?", attendance.AttendanceID).
+			Update("notified_at", time.Now()).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark absence of student %s as notified: %w", nsn, err)
+		}
+
+		job.TotalRecipients++
+		job.TotalMessages += len(msgs)
+		notified = append(notified, nsn)
+	}
+
+	job.Skipped = skipped
+	job.Duplicates = duplicates
+	if err := tx.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create notification job: %w", err)
+	}
+
+	if _, err := m.queueEscalations(tx, notified, userID); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
 }
 
-func (m *senderRepository) sendEmailTestScore(idv *domain.IndividualExamScore, body string) error {
+// digestPending reports whether absences of the day are held back for a digest that has not gone out yet.
+func digestPending(setting *domain.SchoolSetting, now time.Time) bool {
+	if setting.AbsenceMode != domain.AbsenceModeDigest {
+		return false
+	}
+	return setting.LastDigestDate == nil || setting.LastDigestDate.Format("2006-01-02") < now.Format("2006-01-02")
+}
 
-	tNow := time.Now()
+// holdForDigest reports whether today's absences wait for the digest, no digest is generated on
+// non-school days so absences recorded then are notified right away.
+func holdForDigest(tx *gorm.DB, setting *domain.SchoolSetting) (bool, error) {
+	now := schoolNow(setting)
+	if !digestPending(setting, now) {
+		return false, nil
+	}
+	return isSchoolDay(tx, setting, now)
+}
+
+// SendDigest queues one summary per student of today's absences once the digest cutoff has passed.
+// It returns a nil job outside digest mode, before the cutoff, on non-school days, or when today's
+// digest already went out.
+func (m *senderRepository) SendDigest(ctx context.Context) (*domain.NotificationJob, error) {
+	setting, err := loadSchoolSetting(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
 
-	// Format the date and time
-	formattedDate := tNow.Format("02/01/2006")
-	hourOnly := tNow.Format("15")
-	hourAndMinute := tNow.Format("15:04")
+	tNow := schoolNow(setting)
+	if !digestPending(setting, tNow) {
+		return nil, nil
+	}
 
-	intHourOnly, err := strconv.Atoi(hourOnly)
+	schoolDay, err := isSchoolDay(m.db.WithContext(ctx), setting, tNow)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !schoolDay {
+		return nil, nil
 	}
 
-	isAM := "AM"
-	if intHourOnly >= 12 {
-		isAM = "PM"
+	cutoff, err := time.Parse("15:04", setting.DigestCutoff)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest cutoff %s: %w", setting.DigestCutoff, err)
 	}
+	if tNow.Hour()*60+tNow.Minute() < cutoff.Hour()*60+cutoff.Minute() {
+		return nil, nil
+	}
+
+	today := tNow.Format("2006-01-02")
+
+	var job *domain.NotificationJob
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Claim the day first so that concurrent runners cannot send the digest twice
+		claim := tx.Model(&domain.SchoolSetting{}).
+			Where("school_setting_id = ? AND (last_digest_date IS NULL OR last_digest_date < ?)", domain.SchoolSettingID, today).
+			Update("last_digest_date", today)
+		if claim.Error != nil {
+			return fmt.Errorf("failed to claim the daily digest: %w", claim.Error)
+		}
+		if claim.RowsAffected == 0 {
+			return nil
+		}
 
-	subjectTestScoreEmail := fmt.Sprintf("Pemberitahuan Hasil Penilaian %s pada %s %s, tanggal %s", idv.Student.Name, hourAndMinute, isAM, formattedDate)
+		var attendances []domain.Attendance
+		err := tx.Preload("Subject").
+			Where("date = ? AND status = ? AND notified_at IS NULL", today, domain.AttendanceAbsent).
+			Order("student_nsn, session, subject_code").
+			Find(&attendances).Error
+		if err != nil {
+			return fmt.Errorf("failed to fetch absences: %w", err)
+		}
 
-	msg := "From: " + m.emailSender + "\r\n" +
-		"To: " + *idv.Student.Parent.Email + "\r\n" +
-		"Subject: " + subjectTestScoreEmail + "\r\n" +
-		"MIME-Version: 1.0\r\n" +
-		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
-		body
+		if len(attendances) == 0 {
+			return nil
+		}
+
+		job, err = m.queueDigests(tx, attendances)
+		return err
+	})
 
-	err = smtp.SendMail(m.smtpAdress, m.client, m.emailSender, []string{*idv.Student.Parent.Email}, []byte(msg))
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return nil, err
 	}
-	return nil
+
+	return job, nil
 }
 
-func (m *senderRepository) sendWA(ctx context.Context, payload *domain.StudentAndParent, body string) error {
-	completeFormat := fmt.Sprintf("%s%s", "62", payload.Parent.Telephone[1:])
+// queueDigests renders one summary per student listing every subject missed, the subjects already
+// notified within the dedup window are left out of the summary.
+func (m *senderRepository) queueDigests(tx *gorm.DB, attendances []domain.Attendance) (*domain.NotificationJob, error) {
+	language := messengerLanguage()
+	job := domain.NotificationJob{
+		JobID:     uuid.NewString(),
+		EventType: domain.EventAbsenceDigest,
+	}
+	var skipped, duplicates, notified []string
 
-	jid := types.NewJID(completeFormat, types.DefaultUserServer)
+	templates, err := loadTemplateSet(tx, domain.EventAbsenceDigest)
+	if err != nil {
+		return nil, err
+	}
 
-	conversationMessage := &waE2E.Message{
-		Conversation: &body,
+	// Group the absences per student, keeping the order they were fetched in
+	var order []string
+	perStudent := make(map[string][]domain.Attendance)
+	for _, attendance := range attendances {
+		if _, exists := perStudent[attendance.StudentNSN]; !exists {
+			order = append(order, attendance.StudentNSN)
+		}
+		perStudent[attendance.StudentNSN] = append(perStudent[attendance.StudentNSN], attendance)
 	}
 
-	_, err := m.meowClient.SendMessage(ctx, jid, conversationMessage)
-	if err != nil {
-		fmt.Println("meow client error")
-		return err
+	for _, nsn := range order {
+		absences := perStudent[nsn]
+
+		student, err := fetchStudentDetails(tx, nsn)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+
+		if student.Parent.OptedOut(domain.EventAbsence) {
+			skipped = append(skipped, optedOutMessage(nsn, domain.EventAbsence))
+			continue
+		}
+
+		excused, err := isExcused(tx, nsn, absences[0].Date)
+		if err != nil {
+			return nil, err
+		}
+		if excused {
+			skipped = append(skipped, excusedMessage(nsn, absences[0].Date))
+			continue
+		}
+
+		var pending []domain.Attendance
+		for _, attendance := range absences {
+			duplicate, err := m.alreadyNotified(tx, attendance)
+			if err != nil {
+				return nil, err
+			}
+			if !duplicate {
+				pending = append(pending, attendance)
+			}
+		}
+		if len(pending) == 0 {
+			duplicates = append(duplicates, nsn)
+			continue
+		}
+
+		data := m.absenceTemplateData(student, pending[0].Subject)
+		data.Date = pending[0].Date.Format("02/01/2006")
+
+		attendanceIDs := make([]int, 0, len(pending))
+		for _, attendance := range pending {
+			data.Subjects = append(data.Subjects, attendance.Subject)
+			attendanceIDs = append(attendanceIDs, attendance.AttendanceID)
+		}
+
+		msgs, err := m.buildOutboxMessages(job.JobID, domain.EventAbsenceDigest, templates, language, data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render digest for student %s: %w", nsn, err)
+		}
+		if len(msgs) == 0 {
+			skipped = append(skipped, fmt.Sprintf("parent of student %s has no reachable channel", nsn))
+			continue
+		}
+
+		for _, attendance := range pending {
+			// The history rows are flipped together by the outbox through the job ID
+			_, err := logNotificationHistory(tx, nsn, attendance.SubjectCode, student.Student.ParentID, attendance.UserID, &attendance.AttendanceID, job.JobID)
+			if err != nil {
+				return nil, fmt.Errorf("failed saving the data to notification history, error: %v", err)
+			}
+		}
+
+		if err := tx.Create(&msgs).Error; err != nil {
+			return nil, fmt.Errorf("failed to queue digest for student %s: %w", nsn, err)
+		}
+
+		err = tx.Model(&domain.Attendance{}).
+			Where("attendance_id IN (?)", attendanceIDs).
+			Update("notified_at", time.Now()).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark absences of student %s as notified: %w", nsn, err)
+		}
+
+		job.TotalRecipients++
+		job.TotalMessages += len(msgs)
+		notified = append(notified, nsn)
 	}
-	return nil
+
+	job.Skipped = skipped
+	job.Duplicates = duplicates
+	if err := tx.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create notification job: %w", err)
+	}
+
+	if _, err := m.queueEscalations(tx, notified, nil); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
 }
 
-func (m *senderRepository) sendWATestScore(ctx context.Context, idv *domain.IndividualExamScore, strBody string) error {
-	completeFormat := fmt.Sprintf("%s%s", "62", idv.Student.Parent.Telephone[1:])
+// queueEscalations evaluates the escalation rules for the students just notified and queues, for every
+// rule that fired, a stronger worded message to the parent and an alert to ADMIN_EMAIL when it is set.
+// The messages go out under a job of their own, nil is returned when no rule fired.
+func (m *senderRepository) queueEscalations(tx *gorm.DB, nsnList []string, userID *int) (*domain.NotificationJob, error) {
+	if len(nsnList) == 0 {
+		return nil, nil
+	}
 
-	jid := types.NewJID(completeFormat, types.DefaultUserServer)
+	now := time.Now()
+	alerts, err := evaluateEscalations(tx, nsnList, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, nil
+	}
 
-	conversationMessage := &waE2E.Message{
-		Conversation: &strBody,
+	language := messengerLanguage()
+	job := domain.NotificationJob{
+		JobID:     uuid.NewString(),
+		EventType: domain.EventAbsenceEscalation,
+		UserID:    userID,
 	}
+	var skipped []string
 
-	_, err := m.meowClient.SendMessage(ctx, jid, conversationMessage)
+	templates, err := loadTemplateSet(tx, domain.EventAbsenceEscalation)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	var alertTemplates templateSet
+	_, emailEnabled := m.notifiers.Get(domain.ChannelEmail)
+	notifyAdmin := m.adminEmail != nil && emailEnabled
+	if notifyAdmin {
+		alertTemplates, err = loadTemplateSet(tx, domain.EventEscalationAlert)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, alert := range alerts {
+		student, err := fetchStudentDetails(tx, alert.StudentNSN)
+		if err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+
+		data := m.newTemplateData(now)
+		data.Student = student.Student
+		data.Parent = student.Parent
+		data.Absences = alert.Absences
+		data.Sessions = alert.Sessions
+		data.Period = alert.EscalationRule.Period
+
+		var msgs []domain.OutboxMessage
+		if student.Parent.OptedOut(domain.EventAbsence) {
+			skipped = append(skipped, optedOutMessage(alert.StudentNSN, domain.EventAbsence))
+		} else {
+			msgs, err = m.buildOutboxMessages(job.JobID, domain.EventAbsenceEscalation, templates, language, data, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render escalation for student %s: %w", alert.StudentNSN, err)
+			}
+			if len(msgs) == 0 {
+				skipped = append(skipped, fmt.Sprintf("parent of student %s has no reachable channel", alert.StudentNSN))
+			} else {
+				job.TotalRecipients++
+			}
+		}
+
+		if notifyAdmin {
+			rendered, err := alertTemplates.render(language, domain.ChannelEmail, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render escalation alert for student %s: %w", alert.StudentNSN, err)
+			}
+
+			msgs = append(msgs, domain.OutboxMessage{
+				JobID:          job.JobID,
+				EventType:      domain.EventEscalationAlert,
+				Channel:        domain.ChannelEmail,
+				StudentNSN:     alert.StudentNSN,
+				RecipientName:  "Admin",
+				RecipientEmail: m.adminEmail,
+				Subject:        rendered.Subject,
+				Body:           rendered.Body,
+				Status:         domain.OutboxStatusPending,
+			})
+		}
+
+		if len(msgs) == 0 {
+			continue
+		}
+
+		if err := tx.Create(&msgs).Error; err != nil {
+			return nil, fmt.Errorf("failed to queue escalation for student %s: %w", alert.StudentNSN, err)
+		}
+		job.TotalMessages += len(msgs)
+
+		err = tx.Model(&domain.EscalationAlert{}).
+			Where("escalation_alert_id = ?", alert.EscalationAlertID).
+			Update("job_id", job.JobID).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to link escalation of student %s to its job: %w", alert.StudentNSN, err)
+		}
+	}
+
+	job.Skipped = skipped
+	if err := tx.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create notification job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// alreadyNotified reports whether a notice about the same absence (student, subject, date and session)
+// went out before, only within the dedup window when one is configured.
+func (m *senderRepository) alreadyNotified(tx *gorm.DB, attendance domain.Attendance) (bool, error) {
+	// An attendance is unique per student, subject, date and session, its ID stands for all four
+	query := tx.Model(&domain.AttendanceNotificationHistory{}).Where("attendance_id = ?", attendance.AttendanceID)
+	if m.dedupWindow > 0 {
+		query = query.Where("created_at >= ?", time.Now().Add(-m.dedupWindow))
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, fmt.Errorf("could not check notification history of student %s: %w", attendance.StudentNSN, err)
+	}
+	return count > 0, nil
 }
 
-func (m *senderRepository) initTextWithSubject(payload *domain.StudentAndParent, subjectName string) (*string, *string, error) {
-	tNow := time.Now()
+// PreviewMass renders the absence notifications SendMass would queue, without logging history or queueing anything.
+func (m *senderRepository) PreviewMass(ctx context.Context, nsnList *[]string, subjectCode string) (*domain.NotificationPreviewResult, error) {
+	db := m.db.WithContext(ctx)
+	language := messengerLanguage()
 
-	// Format the date and time
-	formattedDate := tNow.Format("02/01/2006") // DD/MM/YYYY format
-	hourOnly := tNow.Format("15")              // 24-hour format
-	hourAndMinute := tNow.Format("15:04")      // HH:MM format
+	var subject domain.Subject
+	err := db.Where("subject_code = ?", subjectCode).First(&subject).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch subject details: %v", err)
+	}
 
-	intHourOnly, err := strconv.Atoi(hourOnly)
+	templates, err := loadTemplateSet(db, domain.EventAbsence)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	isAM := "AM"
-	if intHourOnly >= 12 {
-		isAM = "PM"
+	result := domain.NotificationPreviewResult{
+		EventType: domain.EventAbsence,
+		Messages:  []domain.NotificationPreview{},
 	}
 
-	subject := fmt.Sprintf("Notification of Absence for %s at %s %s on %s", payload.Student.Name, hourAndMinute, isAM, formattedDate)
+	for _, nsn := range *nsnList {
+		student, err := fetchStudentDetails(db, nsn)
+		if err != nil {
+			result.Skipped = append(result.Skipped, err.Error())
+			continue
+		}
+
+		if student.Parent.OptedOut(domain.EventAbsence) {
+			result.Skipped = append(result.Skipped, optedOutMessage(nsn, domain.EventAbsence))
+			continue
+		}
 
-	if payload.Parent.Gender == "male" {
-		bodyMale := fmt.Sprintf(`SINOAN Service 🔔
+		excused, err := isExcused(db, nsn, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if excused {
+			result.Skipped = append(result.Skipped, excusedMessage(nsn, time.Now()))
+			continue
+		}
 
-Dear Mr. %s,
+		data := m.absenceTemplateData(student, subject)
+		msgs, err := m.buildOutboxMessages("", domain.EventAbsence, templates, language, data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render notification for student %s: %w", nsn, err)
+		}
+		if len(msgs) == 0 {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("parent of student %s has no reachable channel", nsn))
+			continue
+		}
 
-We would like to inform you that your child,
+		result.Messages = append(result.Messages, toPreviews(msgs, student.Student.Name)...)
+	}
 
-NSN: %s,
-Name: %s, 
-Class: %d %s.
+	return &result, nil
+}
 
-was absent from the lesson "%s" on %s at %s %s.
+// PreviewTestScores renders the exam result notifications SendTestScores would queue, sent_at is left untouched.
+func (m *senderRepository) PreviewTestScores(ctx context.Context, examType string) (*domain.NotificationPreviewResult, error) {
+	db := m.db.WithContext(ctx)
+	language := messengerLanguage()
 
-We have not yet received any reason for the absence. We kindly ask you to provide confirmation or further information regarding your child's condition.
+	results, skipped, err := collectExamResults(db, false)
+	if err != nil {
+		return nil, err
+	}
 
-If you have any questions or require further assistance, please feel free to contact us at %s.
+	templates, err := loadTemplateSet(db, domain.EventExamResult)
+	if err != nil {
+		return nil, err
+	}
 
-Thank you for your attention and cooperation.`, payload.Parent.Name, payload.Student.StudentNSN, payload.Student.Name, payload.Student.Grade, payload.Student.GradeLabel, strings.ToUpper(subjectName), formattedDate, hourAndMinute, isAM, m.schoolPhone)
+	result := domain.NotificationPreviewResult{
+		EventType: domain.EventExamResult,
+		Messages:  []domain.NotificationPreview{},
+		Skipped:   skipped,
+	}
 
-		return &subject, &bodyMale, nil
-	} else {
-		bodyFemale := fmt.Sprintf(`SINOAN Service 🔔
+	for _, idv := range results {
+		if idv.Student.Parent.OptedOut(domain.EventExamResult) {
+			result.Skipped = append(result.Skipped, optedOutMessage(idv.StudentNSN, domain.EventExamResult))
+			continue
+		}
 
-Dear Mrs. %s,
+		data := m.examResultTemplateData(idv, examType)
+		msgs, err := m.buildOutboxMessages("", domain.EventExamResult, templates, language, data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render test score for student %s: %w", idv.StudentNSN, err)
+		}
+		if len(msgs) == 0 {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("parent of student %s has no reachable channel", idv.StudentNSN))
+			continue
+		}
 
-We would like to inform you that your child, 
+		result.Messages = append(result.Messages, toPreviews(msgs, idv.Student.Name)...)
+	}
 
-NSN: %s,
-Name: %s, 
-Class: %d %s.
+	return &result, nil
+}
 
-was absent from the lesson "%s" on %s at %s %s.
+func toPreviews(msgs []domain.OutboxMessage, studentName string) []domain.NotificationPreview {
+	previews := make([]domain.NotificationPreview, 0, len(msgs))
+	for _, msg := range msgs {
+		previews = append(previews, domain.NotificationPreview{
+			ParentID:    msg.ParentID,
+			ParentName:  msg.RecipientName,
+			StudentNSN:  msg.StudentNSN,
+			StudentName: studentName,
+			Channel:     msg.Channel,
+			Telephone:   msg.RecipientTelephone,
+			Email:       msg.RecipientEmail,
+			Subject:     msg.Subject,
+			Body:        msg.Body,
+		})
+	}
+	return previews
+}
 
-We have not yet received any reason for the absence. We kindly ask you to provide confirmation or further information regarding your child's condition.
+// GetJobStatus reports the progress of a bulk send, with the outcome of every
+// channel per recipient taken from the job's outbox messages.
+func (m *senderRepository) GetJobStatus(ctx context.Context, jobID string) (*domain.NotificationJobStatus, error) {
+	var job domain.NotificationJob
+	err := m.db.WithContext(ctx).Where("job_id = ?", jobID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("notification job %s not found: %w", jobID, err)
+		}
+		return nil, fmt.Errorf("could not fetch notification job: %v", err)
+	}
 
-If you have any questions or require further assistance, please feel free to contact us at %s.
+	var msgs []domain.OutboxMessage
+	err = m.db.WithContext(ctx).Where("job_id = ?", jobID).Order("outbox_message_id").Find(&msgs).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch job messages: %v", err)
+	}
 
-Thank you for your attention and cooperation.`, payload.Parent.Name, payload.Student.StudentNSN, payload.Student.Name, payload.Student.Grade, payload.Student.GradeLabel, strings.ToUpper(subjectName), formattedDate, hourAndMinute, isAM, m.schoolPhone)
-		return &subject, &bodyFemale, nil
+	result := domain.NotificationJobStatus{
+		Job:        job,
+		Recipients: []domain.JobRecipientStatus{},
 	}
-}
 
-func (m *senderRepository) inisialisasiTeksDenganSubjek(payload *domain.StudentAndParent, subjectName string) (*string, *string, error) {
-	tNow := time.Now()
+	recipientIndex := make(map[string]int)
+	for _, msg := range msgs {
+		switch msg.Status {
+		case domain.OutboxStatusSent:
+			result.Sent++
+		case domain.OutboxStatusDead:
+			result.Failed++
+		default:
+			result.Pending++
+		}
 
-	// Format tanggal dan waktu
-	formattedDate := tNow.Format("02/01/2006") // Format DD/MM/YYYY
-	hourOnly := tNow.Format("15")              // Format 24 jam
-	hourAndMinute := tNow.Format("15:04")      // Format HH:MM
+		key := fmt.Sprintf("%d/%s", msg.ParentID, msg.StudentNSN)
+		idx, exists := recipientIndex[key]
+		if !exists {
+			result.Recipients = append(result.Recipients, domain.JobRecipientStatus{
+				ParentID:      msg.ParentID,
+				StudentNSN:    msg.StudentNSN,
+				RecipientName: msg.RecipientName,
+			})
+			idx = len(result.Recipients) - 1
+			recipientIndex[key] = idx
+		}
+
+		result.Recipients[idx].Channels = append(result.Recipients[idx].Channels, domain.JobChannelOutcome{
+			OutboxMessageID: msg.OutboxMessageID,
+			Channel:         msg.Channel,
+			Status:          msg.Status,
+			Attempts:        msg.Attempts,
+			LastError:       msg.LastError,
+			SentAt:          msg.SentAt,
+		})
+	}
+
+	switch {
+	case len(msgs) > 0 && result.Pending == len(msgs):
+		result.Status = domain.JobStatusQueued
+	case result.Pending > 0:
+		result.Status = domain.JobStatusInProgress
+	case result.Failed > 0 || len(job.Skipped) > 0:
+		result.Status = domain.JobStatusCompletedWithErrors
+	default:
+		result.Status = domain.JobStatusCompleted
+	}
 
-	intHourOnly, err := strconv.Atoi(hourOnly)
+	return &result, nil
+}
+
+func fetchStudentDetails(db *gorm.DB, nsn string) (*domain.StudentAndParent, error) {
+	var student domain.Student
+	var parent domain.Parent
+
+	err := db.Where("student_nsn = ?", nsn).Preload("Parent").First(&student).Error
 	if err != nil {
-		return nil, nil, err
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("student with StudentNSN %s not found", nsn)
+		}
+		return nil, fmt.Errorf("could not fetch student details: %v", err)
 	}
 
-	isAM := "AM"
-	if intHourOnly >= 12 {
-		isAM = "PM"
+	err = db.Where("parent_id = ? AND deleted_at IS NULL", student.ParentID).First(&parent).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("parent with ID %d not found", student.ParentID)
+		}
+		return nil, fmt.Errorf("could not fetch parent details: %v", err)
 	}
 
-	subject := fmt.Sprintf("Pemberitahuan Ketidakhadiran untuk %s pada %s %s tanggal %s", payload.Student.Name, hourAndMinute, isAM, formattedDate)
+	return &domain.StudentAndParent{
+		Student: student,
+		Parent:  parent,
+	}, nil
+}
 
-	if payload.Parent.Gender == "male" {
-		bodyMale := fmt.Sprintf(`Layanan SINOAN 🔔
+func optedOutMessage(studentNSN, eventType string) string {
+	return fmt.Sprintf("parent of student %s opted out of %s notifications", studentNSN, eventType)
+}
 
-Yth. Bapak %s,
+func excusedMessage(studentNSN string, date time.Time) string {
+	return fmt.Sprintf("student %s has an excuse covering %s", studentNSN, date.Format("02/01/2006"))
+}
 
-Kami ingin memberitahukan bahwa anak bapak,
+// buildOutboxMessages renders one outbox row per enabled channel the parent can be reached on,
+// in the parent's preferred language when set and the default language otherwise. A parent that
+// can be reached on neither email nor WhatsApp gets an SMS instead.
+func (m *senderRepository) buildOutboxMessages(jobID, eventType string, templates templateSet, defaultLanguage string, data domain.TemplateData, historyID *int) ([]domain.OutboxMessage, error) {
+	var msgs []domain.OutboxMessage
+	parent := data.Parent
+
+	newMessage := func(channel string) error {
+		// SMS reads the WhatsApp wording unless the event has its own SMS template
+		source := channel
+		if channel == domain.ChannelSMS && !templates.has(defaultLanguage, channel) {
+			source = domain.ChannelWhatsApp
+		}
 
-NSN: %s,
-Nama: %s, 
-Kelas: %d %s.
+		language := defaultLanguage
+		if parent.PreferredLanguage != nil && templates.has(*parent.PreferredLanguage, source) {
+			language = *parent.PreferredLanguage
+		}
 
-tidak hadir pada pelajaran "%s" tanggal %s pukul %s %s.
+		rendered := data
+		rendered.ExamType = localizeExamType(data.ExamType, language)
 
-Kami belum menerima alasan ketidakhadiran tersebut. Kami mohon bapak dapat memberikan konfirmasi atau informasi lebih lanjut mengenai kondisi anak bapak.
+		msg, err := templates.render(language, source, rendered)
+		if err != nil {
+			return err
+		}
 
-Jika bapak memiliki pertanyaan atau membutuhkan bantuan lebih lanjut, jangan ragu untuk menghubungi kami di %s.
+		msgs = append(msgs, domain.OutboxMessage{
+			JobID:                 jobID,
+			EventType:             eventType,
+			Channel:               channel,
+			ParentID:              parent.ParentID,
+			StudentNSN:            data.Student.StudentNSN,
+			RecipientName:         parent.Name,
+			RecipientTelephone:    parent.Telephone,
+			RecipientEmail:        parent.Email,
+			Subject:               msg.Subject,
+			Body:                  msg.Body,
+			Status:                domain.OutboxStatusPending,
+			NotificationHistoryID: historyID,
+		})
+		return nil
+	}
 
-Terima kasih atas perhatian dan kerjasamanya.`, payload.Parent.Name, payload.Student.StudentNSN, payload.Student.Name, payload.Student.Grade, payload.Student.GradeLabel, strings.ToUpper(subjectName), formattedDate, hourAndMinute, isAM, m.schoolPhone)
+	if _, ok := m.notifiers.Get(domain.ChannelEmail); ok && parent.ChannelEnabled(domain.ChannelEmail) && parent.Email != nil && *parent.Email != "" {
+		if err := newMessage(domain.ChannelEmail); err != nil {
+			return nil, err
+		}
+	}
 
-		return &subject, &bodyMale, nil
-	} else {
-		bodyFemale := fmt.Sprintf(`Layanan SINOAN 🔔
+	if _, ok := m.notifiers.Get(domain.ChannelWhatsApp); ok && parent.ChannelEnabled(domain.ChannelWhatsApp) && parent.Telephone != "" {
+		if err := newMessage(domain.ChannelWhatsApp); err != nil {
+			return nil, err
+		}
+	}
 
-Yth. Ibu %s,
+	if _, ok := m.notifiers.Get(domain.ChannelSMS); ok && len(msgs) == 0 && parent.ChannelEnabled(domain.ChannelSMS) && parent.Telephone != "" {
+		if err := newMessage(domain.ChannelSMS); err != nil {
+			return nil, err
+		}
+	}
 
-Kami ingin memberitahukan bahwa anak ibu, 
+	return msgs, nil
+}
 
-NSN: %s,
-Nama: %s, 
-Kelas: %d %s.
+func (m *senderRepository) newTemplateData(now time.Time) domain.TemplateData {
+	return newTemplateData(now, m.schoolPhone)
+}
 
-tidak hadir pada pelajaran "%s" tanggal %s pukul %s %s.
+// newTemplateData fills in the fields every template shares, the caller adds the event specific ones.
+func newTemplateData(now time.Time, schoolPhone string) domain.TemplateData {
+	meridiem := "AM"
+	if now.Hour() >= 12 {
+		meridiem = "PM"
+	}
 
-Kami belum menerima alasan ketidakhadiran tersebut. Kami mohon ibu dapat memberikan konfirmasi atau informasi lebih lanjut mengenai kondisi anak ibu.
+	return domain.TemplateData{
+		Date:        now.Format("02/01/2006"), // DD/MM/YYYY format
+		Time:        now.Format("15:04"),      // HH:MM format
+		Meridiem:    meridiem,
+		SchoolPhone: schoolPhone,
+	}
+}
 
-Jika ibu memiliki pertanyaan atau membutuhkan bantuan lebih lanjut, jangan ragu untuk menghubungi kami di %s.
+func (m *senderRepository) absenceTemplateData(student *domain.StudentAndParent, subject domain.Subject) domain.TemplateData {
+	data := m.newTemplateData(time.Now())
+	data.Student = student.Student
+	data.Parent = student.Parent
+	data.Subject = subject
+	return data
+}
 
-Terima kasih atas perhatian dan kerjasamanya.`, payload.Parent.Name, payload.Student.StudentNSN, payload.Student.Name, payload.Student.Grade, payload.Student.GradeLabel, strings.ToUpper(subjectName), formattedDate, hourAndMinute, isAM, m.schoolPhone)
-		return &subject, &bodyFemale, nil
+// attachReportCard renders the student's report card once, in the parent's language when supported,
+// and attaches it to every message queued for the student.
+func (m *senderRepository) attachReportCard(msgs []domain.OutboxMessage, idv domain.IndividualExamScore, examType, defaultLanguage string) error {
+	language := defaultLanguage
+	if preferred := idv.Student.Parent.PreferredLanguage; preferred != nil && domain.IsSupportedLanguage(*preferred) {
+		language = *preferred
 	}
+
+	pdf, err := renderReportCard(idv, examType, language, m.schoolName, m.schoolPhone, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to render report card for student %s: %w", idv.StudentNSN, err)
+	}
+
+	filename := reportCardFilename(idv.StudentNSN, language)
+	contentType := "application/pdf"
+	for i := range msgs {
+		msgs[i].AttachmentName = &filename
+		msgs[i].AttachmentType = &contentType
+		msgs[i].Attachment = pdf
+	}
+	return nil
 }
 
-func (m *senderRepository) logNotificationHistory(StudentNSN, subjectCode string, parentID, userID int, whatsappSuccess, emailSuccess bool) error {
+func (m *senderRepository) examResultTemplateData(idv domain.IndividualExamScore, examType string) domain.TemplateData {
+	data := m.newTemplateData(time.Now())
+	data.Student = idv.Student
+	data.Parent = idv.Student.Parent
+	data.ExamType = examType
+	data.Scores = idv.SubjectAndScoreResult
+	return data
+}
+
+// messengerLanguage is the language set by MESSENGER_LANGUAGE, English unless set to ind.
+func messengerLanguage() string {
+	if strings.ToLower(os.Getenv("MESSENGER_LANGUAGE")) == domain.LanguageIndonesian {
+		return domain.LanguageIndonesian
+	}
+	return domain.LanguageEnglish
+}
+
+func logNotificationHistory(db *gorm.DB, StudentNSN, subjectCode string, parentID, userID int, attendanceID *int, jobID string) (*domain.AttendanceNotificationHistory, error) {
 	history := &domain.AttendanceNotificationHistory{
+		AttendanceID:   attendanceID,
+		JobID:          &jobID,
 		StudentNSN:     StudentNSN,
 		ParentID:       parentID,
 		UserID:         userID,
 		SubjectCode:    subjectCode,
-		WhatsappStatus: whatsappSuccess,
-		EmailStatus:    emailSuccess,
+		WhatsappStatus: false,
+		EmailStatus:    false,
 	}
 
-	err := m.db.Create(history).Error
+	err := db.Create(history).Error
 	if err != nil {
-		return fmt.Errorf("could not log notification history: %v", err)
+		return nil, fmt.Errorf("could not log notification history: %v", err)
 	}
 
-	return nil
+	return history, nil
 }
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"notification/domain"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // school timezones must resolve on hosts without a zoneinfo database
+
+	"gorm.io/gorm"
+)
+
+type schoolSettingRepository struct {
+	db *gorm.DB
+}
+
+func NewSchoolSettingRepository(db *gorm.DB) domain.SchoolSettingRepo {
+	return &schoolSettingRepository{
+		db: db,
+	}
+}
+
+func (r *schoolSettingRepository) GetSchoolSetting(ctx context.Context) (*domain.SchoolSetting, error) {
+	return loadSchoolSetting(r.db.WithContext(ctx))
+}
+
+func (r *schoolSettingRepository) UpdateSchoolSetting(ctx context.Context, payload *domain.SchoolSettingPayload) (*domain.SchoolSetting, error) {
+	fields := make(map[string]interface{})
+
+	if payload.AbsenceMode != nil {
+		if *payload.AbsenceMode != domain.AbsenceModeImmediate && *payload.AbsenceMode != domain.AbsenceModeDigest {
+			return nil, fmt.Errorf("absence mode %s is not supported, must be immediate or digest", *payload.AbsenceMode)
+		}
+		fields["absence_mode"] = *payload.AbsenceMode
+	}
+
+	if payload.DigestCutoff != nil {
+		if _, err := time.Parse("15:04", *payload.DigestCutoff); err != nil {
+			return nil, fmt.Errorf("invalid digest cutoff %s, expected format HH:MM", *payload.DigestCutoff)
+		}
+		fields["digest_cutoff"] = *payload.DigestCutoff
+	}
+
+	if payload.Timezone != nil {
+		if _, err := time.LoadLocation(*payload.Timezone); err != nil {
+			return nil, fmt.Errorf("unknown timezone %s", *payload.Timezone)
+		}
+		fields["timezone"] = *payload.Timezone
+	}
+
+	// Quiet hours are set or cleared together, a half open range would never end
+	if payload.QuietHoursStart != nil || payload.QuietHoursEnd != nil {
+		if payload.QuietHoursStart == nil || payload.QuietHoursEnd == nil {
+			return nil, fmt.Errorf("quiet hours start and end must be set together")
+		}
+
+		if *payload.QuietHoursStart == "" && *payload.QuietHoursEnd == "" {
+			fields["quiet_hours_start"] = nil
+			fields["quiet_hours_end"] = nil
+		} else {
+			for _, v := range []string{*payload.QuietHoursStart, *payload.QuietHoursEnd} {
+				if _, err := time.Parse("15:04", v); err != nil {
+					return nil, fmt.Errorf("invalid quiet hours %s, expected format HH:MM", v)
+				}
+			}
+			fields["quiet_hours_start"] = *payload.QuietHoursStart
+			fields["quiet_hours_end"] = *payload.QuietHoursEnd
+		}
+	}
+
+	if payload.SchoolDays != nil {
+		days, err := parseSchoolDays(*payload.SchoolDays)
+		if err != nil {
+			return nil, err
+		}
+		if len(days) == 0 {
+			return nil, fmt.Errorf("at least one school day is required")
+		}
+		fields["school_days"] = *payload.SchoolDays
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no school setting to update")
+	}
+	fields["updated_at"] = time.Now()
+
+	err := r.db.WithContext(ctx).Model(&domain.SchoolSetting{}).
+		Where("school_setting_id = ?", domain.SchoolSettingID).
+		Updates(fields).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not update school setting: %w", err)
+	}
+
+	return loadSchoolSetting(r.db.WithContext(ctx))
+}
+
+func (r *schoolSettingRepository) GetAllSchoolHolidays(ctx context.Context) (*[]domain.SchoolHoliday, error) {
+	var holidays []domain.SchoolHoliday
+	err := r.db.WithContext(ctx).Order("start_date DESC").Find(&holidays).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get school holidays: %w", err)
+	}
+	return &holidays, nil
+}
+
+func (r *schoolSettingRepository) CreateSchoolHoliday(ctx context.Context, payload *domain.SchoolHolidayPayload) (*domain.SchoolHoliday, error) {
+	startDate, err := time.Parse("2006-01-02", payload.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date %s, expected format YYYY-MM-DD", payload.StartDate)
+	}
+
+	endDate, err := time.Parse("2006-01-02", payload.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date %s, expected format YYYY-MM-DD", payload.EndDate)
+	}
+
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("end date %s is before start date %s", payload.EndDate, payload.StartDate)
+	}
+
+	holiday := domain.SchoolHoliday{
+		Name:      strings.TrimSpace(payload.Name),
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+
+	if err := r.db.WithContext(ctx).Create(&holiday).Error; err != nil {
+		return nil, fmt.Errorf("could not create school holiday: %w", err)
+	}
+
+	return &holiday, nil
+}
+
+func (r *schoolSettingRepository) DeleteSchoolHoliday(ctx context.Context, schoolHolidayID int) error {
+	result := r.db.WithContext(ctx).Where("school_holiday_id = ?", schoolHolidayID).Delete(&domain.SchoolHoliday{})
+	if result.Error != nil {
+		return fmt.Errorf("could not delete school holiday: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("school holiday with ID %d not found", schoolHolidayID)
+	}
+
+	return nil
+}
+
+func loadSchoolSetting(db *gorm.DB) (*domain.SchoolSetting, error) {
+	var setting domain.SchoolSetting
+	err := db.Where("school_setting_id = ?", domain.SchoolSettingID).First(&setting).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get school setting: %w", err)
+	}
+	return &setting, nil
+}
+
+// schoolNow is the current time in the school's timezone, the server's zone is used when it cannot be loaded.
+func schoolNow(setting *domain.SchoolSetting) time.Time {
+	loc, err := time.LoadLocation(setting.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	return time.Now().In(loc)
+}
+
+// inQuietHours reports whether now falls in the quiet hours, a range may run past midnight (e.g. 21:00 to 06:00).
+func inQuietHours(setting *domain.SchoolSetting, now time.Time) bool {
+	if setting.QuietHoursStart == nil || setting.QuietHoursEnd == nil {
+		return false
+	}
+
+	start, errStart := time.Parse("15:04", *setting.QuietHoursStart)
+	end, errEnd := time.Parse("15:04", *setting.QuietHoursEnd)
+	if errStart != nil || errEnd != nil {
+		return false
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	from := start.Hour()*60 + start.Minute()
+	until := end.Hour()*60 + end.Minute()
+
+	if from <= until {
+		return minute >= from && minute < until
+	}
+	return minute >= from || minute < until
+}
+
+// isSchoolDay reports whether lessons are held on the calendar day of now, that is a school day of the week
+// not covered by a holiday.
+func isSchoolDay(db *gorm.DB, setting *domain.SchoolSetting, now time.Time) (bool, error) {
+	days, err := parseSchoolDays(setting.SchoolDays)
+	if err != nil {
+		return false, err
+	}
+	if !days[now.Weekday()] {
+		return false, nil
+	}
+
+	var count int64
+	day := now.Format("2006-01-02")
+	err = db.Model(&domain.SchoolHoliday{}).
+		Where("start_date <= ? AND end_date >= ?", day, day).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("could not check school holidays: %w", err)
+	}
+	return count == 0, nil
+}
+
+func parseSchoolDays(v string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 0 || day > 6 {
+			return nil, fmt.Errorf("school days must be weekday numbers from 0 (Sunday) to 6 (Saturday)")
+		}
+		days[time.Weekday(day)] = true
+	}
+	return days, nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"notification/domain"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+)
+
+func TestInQuietHours(t *testing.T) {
+	clock := func(s string) *string { return &s }
+
+	tests := []struct {
+		name       string
+		start, end *string
+		now        string
+		want       bool
+	}{
+		{name: "off without a range", now: "23:00", want: false},
+		{name: "off with only a start", start: clock("21:00"), now: "23:00", want: false},
+		{name: "invalid range", start: clock("9pm"), end: clock("06:00"), now: "23:00", want: false},
+		{name: "inside a daytime range", start: clock("12:00"), end: clock("13:00"), now: "12:30", want: true},
+		{name: "end of a daytime range is outside", start: clock("12:00"), end: clock("13:00"), now: "13:00", want: false},
+		{name: "wrapping range before midnight", start: clock("21:00"), end: clock("06:00"), now: "22:15", want: true},
+		{name: "wrapping range after midnight", start: clock("21:00"), end: clock("06:00"), now: "05:59", want: true},
+		{name: "wrapping range start is inside", start: clock("21:00"), end: clock("06:00"), now: "21:00", want: true},
+		{name: "wrapping range end is outside", start: clock("21:00"), end: clock("06:00"), now: "06:00", want: false},
+		{name: "outside a wrapping range", start: clock("21:00"), end: clock("06:00"), now: "12:00", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setting := &domain.SchoolSetting{QuietHoursStart: tt.start, QuietHoursEnd: tt.end}
+			now, _ := time.Parse("15:04", tt.now)
+			if got := inQuietHours(setting, now); got != tt.want {
+				t.Errorf("inQuietHours(%s) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInQuietHoursUsesTheSchoolTimezone(t *testing.T) {
+	start, end := "21:00", "06:00"
+	// 22:30 UTC is 05:30 in Jakarta (UTC+7) but already 06:30 in Makassar (UTC+8)
+	instant := time.Date(2025, time.March, 3, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		timezone string
+		want     bool
+	}{
+		{timezone: "Asia/Jakarta", want: true},
+		{timezone: "Asia/Makassar", want: false},
+		{timezone: "UTC", want: true},
+	}
+
+	for _, tt := range tests {
+		loc, err := time.LoadLocation(tt.timezone)
+		if err != nil {
+			t.Fatalf("LoadLocation(%s): %v", tt.timezone, err)
+		}
+
+		setting := &domain.SchoolSetting{Timezone: tt.timezone, QuietHoursStart: &start, QuietHoursEnd: &end}
+		if got := inQuietHours(setting, instant.In(loc)); got != tt.want {
+			t.Errorf("inQuietHours at %s in %s = %v, want %v", instant.Format(time.RFC3339), tt.timezone, got, tt.want)
+		}
+	}
+}
+
+// holidayDB is a gorm handle that never reaches a database, counting school holidays returns holidays
+// and the date asked for is kept in day.
+func holidayDB(t *testing.T, holidays int64, day *string) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+
+	err = db.Callback().Query().Replace("gorm:query", func(tx *gorm.DB) {
+		callbacks.BuildQuerySQL(tx)
+		if len(tx.Statement.Vars) > 0 {
+			*day, _ = tx.Statement.Vars[0].(string)
+		}
+		if count, ok := tx.Statement.Dest.(*int64); ok {
+			*count = holidays
+			tx.RowsAffected = 1
+		}
+	})
+	if err != nil {
+		t.Fatalf("replace query callback: %v", err)
+	}
+	return db
+}
+
+func TestIsSchoolDay(t *testing.T) {
+	monday := time.Date(2025, time.March, 3, 7, 0, 0, 0, time.UTC)
+	saturday := time.Date(2025, time.March, 8, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		schoolDays string
+		now        time.Time
+		holidays   int64
+		want       bool
+		wantErr    bool
+	}{
+		{name: "weekday", schoolDays: "1,2,3,4,5", now: monday, want: true},
+		{name: "weekend", schoolDays: "1,2,3,4,5", now: saturday, want: false},
+		{name: "six day week", schoolDays: "1, 2, 3, 4, 5, 6", now: saturday, want: true},
+		{name: "holiday", schoolDays: "1,2,3,4,5", now: monday, holidays: 1, want: false},
+		{name: "invalid weekday", schoolDays: "1,7", now: monday, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var day string
+			db := holidayDB(t, tt.holidays, &day)
+			setting := &domain.SchoolSetting{SchoolDays: tt.schoolDays}
+
+			got, err := isSchoolDay(db, setting, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("isSchoolDay error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("isSchoolDay = %v, want %v", got, tt.want)
+			}
+			if day != "" && day != tt.now.Format("2006-01-02") {
+				t.Errorf("holidays were looked up for %s, want %s", day, tt.now.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestIsSchoolDayUsesTheSchoolCalendarDay(t *testing.T) {
+	// 18:00 UTC on Friday is already Saturday 01:00 in Jakarta
+	instant := time.Date(2025, time.March, 7, 18, 0, 0, 0, time.UTC)
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+
+	var day string
+	setting := &domain.SchoolSetting{Timezone: "Asia/Jakarta", SchoolDays: "1,2,3,4,5"}
+	got, err := isSchoolDay(holidayDB(t, 0, &day), setting, instant.In(loc))
+	if err != nil {
+		t.Fatalf("isSchoolDay: %v", err)
+	}
+	if got {
+		t.Errorf("isSchoolDay = true on Saturday in Jakarta")
+	}
+
+	// 18:00 UTC on Thursday is Friday in Jakarta, the holidays of Friday apply
+	got, err = isSchoolDay(holidayDB(t, 0, &day), setting, instant.AddDate(0, 0, -1).In(loc))
+	if err != nil {
+		t.Fatalf("isSchoolDay: %v", err)
+	}
+	if !got || day != "2025-03-07" {
+		t.Errorf("isSchoolDay = %v with holidays looked up for %s, want true for 2025-03-07", got, day)
+	}
+}
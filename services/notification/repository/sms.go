@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"notification/domain"
+	"strings"
+	"time"
+)
+
+// SMS
+type smsGatewayNotifier struct {
+	gateway domain.SMSGateway
+	client  *http.Client
+}
+
+// NewSMSGatewayNotifier sends text messages through the HTTP API of an SMS provider, see domain.SMSGateway
+// for how the request is built. It is the fallback for parents that cannot be reached on WhatsApp.
+func NewSMSGatewayNotifier(gateway domain.SMSGateway) domain.Notifier {
+	if gateway.Method == "" {
+		gateway.Method = http.MethodPost
+	}
+	if gateway.BodyFormat == "" {
+		gateway.BodyFormat = domain.SMSBodyJSON
+	}
+	if gateway.ToField == "" {
+		gateway.ToField = "to"
+	}
+	if gateway.MessageField == "" {
+		gateway.MessageField = "message"
+	}
+	if gateway.Timeout <= 0 {
+		gateway.Timeout = 10 * time.Second
+	}
+
+	return &smsGatewayNotifier{
+		gateway: gateway,
+		client:  &http.Client{Timeout: gateway.Timeout},
+	}
+}
+
+func (n *smsGatewayNotifier) Channel() string {
+	return domain.ChannelSMS
+}
+
+// Send posts the text of the message to the gateway, attachments are left out since SMS cannot carry them.
+func (n *smsGatewayNotifier) Send(ctx context.Context, recipient domain.Recipient, message domain.Message) (*domain.SendResult, error) {
+	to, err := internationalTelephone(recipient.Telephone)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := n.newRequest(ctx, to, message.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach sms gateway: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		reply := []rune(strings.TrimSpace(string(body)))
+		if len(reply) > 200 {
+			reply = reply[:200]
+		}
+		return nil, &smsGatewayError{status: resp.StatusCode, body: string(reply)}
+	}
+
+	return &domain.SendResult{
+		MessageID: gatewayMessageID(body),
+		SentAt:    time.Now(),
+	}, nil
+}
+
+func (n *smsGatewayNotifier) newRequest(ctx context.Context, to, text string) (*http.Request, error) {
+	target := strings.NewReplacer(
+		"{to}", url.QueryEscape(to),
+		"{message}", url.QueryEscape(text),
+	).Replace(n.gateway.URL)
+
+	var body io.Reader
+	var contentType string
+
+	switch n.gateway.BodyFormat {
+	case domain.SMSBodyJSON:
+		fields := make(map[string]string, len(n.gateway.Params)+2)
+		for key, value := range n.gateway.Params {
+			fields[key] = value
+		}
+		fields[n.gateway.ToField] = to
+		fields[n.gateway.MessageField] = text
+
+		encoded, err := json.Marshal(fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode sms request: %w", err)
+		}
+		body, contentType = bytes.NewReader(encoded), "application/json"
+	case domain.SMSBodyForm:
+		form := url.Values{}
+		for key, value := range n.gateway.Params {
+			form.Set(key, value)
+		}
+		form.Set(n.gateway.ToField, to)
+		form.Set(n.gateway.MessageField, text)
+		body, contentType = strings.NewReader(form.Encode()), "application/x-www-form-urlencoded"
+	case domain.SMSBodyNone:
+	default:
+		return nil, fmt.Errorf("sms gateway body format %s is not supported, must be json, form or none", n.gateway.BodyFormat)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, n.gateway.Method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sms request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if n.gateway.AuthHeader != "" && n.gateway.AuthValue != "" {
+		req.Header.Set(n.gateway.AuthHeader, n.gateway.AuthValue)
+	}
+
+	return req, nil
+}
+
+// gatewayMessageID picks the message id out of a JSON reply, gateways name it differently and
+// some only answer with plain text, in which case there is no id.
+func gatewayMessageID(body []byte) string {
+	var reply map[string]interface{}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return ""
+	}
+
+	for _, key := range []string{"message_id", "messageId", "id"} {
+		switch id := reply[key].(type) {
+		case string:
+			return id
+		case float64:
+			return fmt.Sprintf("%.0f", id)
+		}
+	}
+	return ""
+}
+
+// smsGatewayError is a rejected request. Client errors are permanent except for timeouts, rate limiting
+// and rejected credentials, which a fixed setup cures like server errors are cured by a retry.
+type smsGatewayError struct {
+	status int
+	body   string
+}
+
+func (e *smsGatewayError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("sms gateway replied %d", e.status)
+	}
+	return fmt.Sprintf("sms gateway replied %d: %s", e.status, e.body)
+}
+
+func (e *smsGatewayError) Permanent() bool {
+	return e.status >= 400 && e.status < 500 &&
+		e.status != http.StatusRequestTimeout && e.status != http.StatusTooManyRequests &&
+		e.status != http.StatusUnauthorized && e.status != http.StatusForbidden
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"notification/domain"
+	"testing"
+)
+
+// gatewayStub records the last request it got and answers with status and reply.
+type gatewayStub struct {
+	status  int
+	reply   string
+	request *http.Request
+	body    string
+}
+
+func (g *gatewayStub) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		g.request, g.body = r, string(body)
+		w.WriteHeader(g.status)
+		w.Write([]byte(g.reply))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSMSGatewayJSONBody(t *testing.T) {
+	stub := &gatewayStub{status: http.StatusOK, reply: `{"message_id":"abc-1"}`}
+	server := stub.start(t)
+
+	notifier := NewSMSGatewayNotifier(domain.SMSGateway{
+		URL:        server.URL + "/send",
+		AuthHeader: "Authorization",
+		AuthValue:  "Bearer secret",
+		Params:     map[string]string{"sender": "SCHOOL"},
+	})
+
+	result, err := notifier.Send(context.Background(), domain.Recipient{Telephone: "081234567"}, domain.Message{Body: "Halo & selamat"})
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if result.MessageID != "abc-1" {
+		t.Errorf("message id = %q, want abc-1", result.MessageID)
+	}
+
+	if stub.request.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", stub.request.Method)
+	}
+	if got := stub.request.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("auth header = %q", got)
+	}
+	if got := stub.request.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("content type = %q", got)
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(stub.body), &fields); err != nil {
+		t.Fatalf("body is not json: %v", err)
+	}
+	want := map[string]string{"to": "6281234567", "message": "Halo & selamat", "sender": "SCHOOL"}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("body %s = %q, want %q", key, fields[key], value)
+		}
+	}
+}
+
+func TestSMSGatewayFormBody(t *testing.T) {
+	stub := &gatewayStub{status: http.StatusAccepted, reply: `{"id":42}`}
+	server := stub.start(t)
+
+	notifier := NewSMSGatewayNotifier(domain.SMSGateway{
+		URL:          server.URL,
+		BodyFormat:   domain.SMSBodyForm,
+		ToField:      "msisdn",
+		MessageField: "text",
+	})
+
+	result, err := notifier.Send(context.Background(), domain.Recipient{Telephone: "+6281234567"}, domain.Message{Body: "Halo"})
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if result.MessageID != "42" {
+		t.Errorf("message id = %q, want 42", result.MessageID)
+	}
+
+	if got := stub.request.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+		t.Errorf("content type = %q", got)
+	}
+	form, err := url.ParseQuery(stub.body)
+	if err != nil {
+		t.Fatalf("body is not a form: %v", err)
+	}
+	if form.Get("msisdn") != "6281234567" || form.Get("text") != "Halo" {
+		t.Errorf("form = %v", form)
+	}
+	if stub.request.Header.Get("Authorization") != "" {
+		t.Errorf("auth header sent although none is configured")
+	}
+}
+
+func TestSMSGatewayURLPlaceholders(t *testing.T) {
+	stub := &gatewayStub{status: http.StatusOK, reply: "OK"}
+	server := stub.start(t)
+
+	notifier := NewSMSGatewayNotifier(domain.SMSGateway{
+		URL:        server.URL + "/send?to={to}&text={message}",
+		Method:     http.MethodGet,
+		BodyFormat: domain.SMSBodyNone,
+	})
+
+	result, err := notifier.Send(context.Background(), domain.Recipient{Telephone: "081234567"}, domain.Message{Body: "a&b c"})
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if result.MessageID != "" {
+		t.Errorf("message id = %q, want none for a plain text reply", result.MessageID)
+	}
+
+	query := stub.request.URL.Query()
+	if query.Get("to") != "6281234567" || query.Get("text") != "a&b c" {
+		t.Errorf("query = %v", query)
+	}
+	if stub.body != "" {
+		t.Errorf("body = %q, want none", stub.body)
+	}
+}
+
+func TestGatewayMessageID(t *testing.T) {
+	cases := map[string]string{
+		`{"message_id":"m1"}`:       "m1",
+		`{"messageId":"m2"}`:        "m2",
+		`{"id":1234567890}`:         "1234567890",
+		`{"status":"queued"}`:       "",
+		`queued`:                    "",
+		`{"message_id":true}`:       "",
+		`{"id":"x","message_id":5}`: "5",
+	}
+	for body, want := range cases {
+		if got := gatewayMessageID([]byte(body)); got != want {
+			t.Errorf("gatewayMessageID(%s) = %q, want %q", body, got, want)
+		}
+	}
+}
+
+func TestSMSGatewayErrorPermanent(t *testing.T) {
+	cases := map[int]bool{
+		http.StatusBadRequest:          true,
+		http.StatusUnauthorized:        false,
+		http.StatusForbidden:           false,
+		http.StatusNotFound:            true,
+		http.StatusRequestTimeout:      false,
+		http.StatusTooManyRequests:     false,
+		http.StatusInternalServerError: false,
+		http.StatusBadGateway:          false,
+	}
+
+	for status, permanent := range cases {
+		stub := &gatewayStub{status: status, reply: `{"error":"nope"}`}
+		server := stub.start(t)
+
+		_, err := NewSMSGatewayNotifier(domain.SMSGateway{URL: server.URL}).
+			Send(context.Background(), domain.Recipient{Telephone: "081234567"}, domain.Message{Body: "Halo"})
+		if err == nil {
+			t.Fatalf("status %d: want an error", status)
+		}
+
+		var failure domain.PermanentFailure
+		if !errors.As(err, &failure) {
+			t.Fatalf("status %d: error %v does not report permanence", status, err)
+		}
+		if failure.Permanent() != permanent {
+			t.Errorf("status %d: permanent = %v, want %v", status, failure.Permanent(), permanent)
+		}
+	}
+}
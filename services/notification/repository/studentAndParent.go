@@ -42,11 +42,12 @@ func (spr *studentParentRepository) ApproveDCR(ctx context.Context, req map[stri
 	var AssociatedStudent []domain.Student
 	tNow := time.Now()
 	var comparedData struct {
-		Name      string
-		Gender    string
-		Telephone string
-		Email     *string
-		UpdatedAt time.Time
+		Name              string
+		Gender            string
+		Telephone         string
+		Email             *string
+		PreferredLanguage *string
+		UpdatedAt         time.Time
 	}
 
 	// Begin transaction
@@ -93,6 +94,12 @@ func (spr *studentParentRepository) ApproveDCR(ctx context.Context, req map[stri
 		}
 	}
 
+	if dcr.NewParentLanguage != nil {
+		if Parent.PreferredLanguage == nil || *dcr.NewParentLanguage != *Parent.PreferredLanguage {
+			comparedData.PreferredLanguage = dcr.NewParentLanguage
+		}
+	}
+
 	// Always update the timestamp
 	comparedData.UpdatedAt = tNow
 
@@ -279,6 +286,12 @@ func (spr *studentParentRepository) CreateStudentAndParent(ctx context.Context,
 		}
 	}
 
+	language, err := normalizeLanguage(req.Parent.PreferredLanguage)
+	if err != nil {
+		errList = append(errList, err.Error())
+	}
+	req.Parent.PreferredLanguage = language
+
 	// Validate parent telephone length
 	parTelLength := len(req.Parent.Telephone)
 	if parTelLength > 13 {
@@ -526,6 +539,68 @@ func (spr *studentParentRepository) ImportCSV(ctx context.Context, payload *[]do
 	return nil, nil
 }
 
+// UpdateParentPreferences changes which channels and events a parent is notified about.
+func (spr *studentParentRepository) UpdateParentPreferences(ctx context.Context, parentID int, prefs *domain.ParentNotificationPreferences) (*domain.Parent, error) {
+	fields := preferenceFields(prefs)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no notification preference to update")
+	}
+	fields["updated_at"] = time.Now()
+
+	result := spr.db.WithContext(ctx).Model(&domain.Parent{}).
+		Where("parent_id = ? AND deleted_at IS NULL", parentID).
+		Updates(fields)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %v", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("parent with ID %d not found", parentID)
+	}
+
+	var parent domain.Parent
+	err := spr.db.WithContext(ctx).Where("parent_id = ?", parentID).First(&parent).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch parent details: %v", err)
+	}
+
+	return &parent, nil
+}
+
+// preferenceFields maps the preferences that are set to their columns
+func preferenceFields(prefs *domain.ParentNotificationPreferences) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if prefs.NotifyEmail != nil {
+		fields["notify_email"] = *prefs.NotifyEmail
+	}
+	if prefs.NotifyWhatsApp != nil {
+		fields["notify_whatsapp"] = *prefs.NotifyWhatsApp
+	}
+	if prefs.NotifySMS != nil {
+		fields["notify_sms"] = *prefs.NotifySMS
+	}
+	if prefs.OptOutAbsence != nil {
+		fields["opt_out_absence"] = *prefs.OptOutAbsence
+	}
+	if prefs.OptOutExamResult != nil {
+		fields["opt_out_exam_result"] = *prefs.OptOutExamResult
+	}
+	return fields
+}
+
+// normalizeLanguage lowers a preferred language and turns an empty one into nil
+func normalizeLanguage(language *string) (*string, error) {
+	if language == nil || strings.TrimSpace(*language) == "" {
+		return nil, nil
+	}
+
+	lowered := strings.ToLower(strings.TrimSpace(*language))
+	if !domain.IsSupportedLanguage(lowered) {
+		return nil, fmt.Errorf("preferred language %s is not supported, must be ind or eng", *language)
+	}
+	return &lowered, nil
+}
+
 // Helper function to check if a string contains any digits
 func containsDigit(s string) bool {
 	for _, r := range s {
@@ -586,6 +661,12 @@ func (spr *studentParentRepository) UpdateStudentAndParent(ctx context.Context,
 		}
 	}
 
+	language, err := normalizeLanguage(req.Parent.PreferredLanguage)
+	if err != nil {
+		errList = append(errList, err.Error())
+	}
+	req.Parent.PreferredLanguage = language
+
 	parTelLength := len(req.Parent.Telephone)
 	if parTelLength > 13 {
 		errList = append(errList, "Parent telephone should not be more than 13 number")
@@ -609,7 +690,7 @@ func (spr *studentParentRepository) UpdateStudentAndParent(ctx context.Context,
 	}
 
 	var studentCountNSN int64
-	err := spr.db.WithContext(ctx).Model(&domain.Student{}).Where("student_nsn = ? AND student_nsn != ?", req.Student.StudentNSN, studentNSN).Count(&studentCountNSN).Error
+	err = spr.db.WithContext(ctx).Model(&domain.Student{}).Where("student_nsn = ? AND student_nsn != ?", req.Student.StudentNSN, studentNSN).Count(&studentCountNSN).Error
 	if err != nil {
 		errList = append(errList, fmt.Sprintf("Error checking for student student nsn: %v", err))
 	} else if studentCountNSN > 0 {
@@ -709,6 +790,19 @@ func (spr *studentParentRepository) UpdateStudentAndParent(ctx context.Context,
 		(req.Parent.Email != nil && student.Parent.Email != nil && *req.Parent.Email != *student.Parent.Email) {
 		updatedParentFields["email"] = req.Parent.Email
 	}
+	if req.Parent.PreferredLanguage != nil &&
+		(student.Parent.PreferredLanguage == nil || *req.Parent.PreferredLanguage != *student.Parent.PreferredLanguage) {
+		updatedParentFields["preferred_language"] = req.Parent.PreferredLanguage
+	}
+	for column, value := range preferenceFields(&domain.ParentNotificationPreferences{
+		NotifyEmail:      req.Parent.NotifyEmail,
+		NotifyWhatsApp:   req.Parent.NotifyWhatsApp,
+		NotifySMS:        req.Parent.NotifySMS,
+		OptOutAbsence:    req.Parent.OptOutAbsence,
+		OptOutExamResult: req.Parent.OptOutExamResult,
+	}) {
+		updatedParentFields[column] = value
+	}
 	if len(updatedParentFields) > 0 {
 		updatedParentFields["updated_at"] = now
 	}
@@ -1118,6 +1212,11 @@ func (spr *studentParentRepository) DataChangeRequest(ctx context.Context, datas
 		}
 	}
 
+	datas.NewParentLanguage, err = normalizeLanguage(datas.NewParentLanguage)
+	if err != nil {
+		return err
+	}
+
 	err = spr.db.WithContext(ctx).Create(&datas).Error
 	if err != nil {
 		return err
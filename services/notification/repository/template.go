@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"notification/domain"
+	"strings"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type messageTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageTemplateRepository(db *gorm.DB) domain.MessageTemplateRepo {
+	return &messageTemplateRepository{
+		db: db,
+	}
+}
+
+func (r *messageTemplateRepository) GetAllTemplates(ctx context.Context) (*[]domain.MessageTemplate, error) {
+	var templates []domain.MessageTemplate
+
+	err := r.db.WithContext(ctx).Order("event_type, language, channel").Find(&templates).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not get message templates: %w", err)
+	}
+
+	return &templates, nil
+}
+
+func (r *messageTemplateRepository) GetTemplateByID(ctx context.Context, templateID int) (*domain.MessageTemplate, error) {
+	var tmpl domain.MessageTemplate
+
+	err := r.db.WithContext(ctx).Where("template_id = ?", templateID).First(&tmpl).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("message template with ID %d not found", templateID)
+		}
+		return nil, fmt.Errorf("could not get message template: %w", err)
+	}
+
+	return &tmpl, nil
+}
+
+func (r *messageTemplateRepository) CreateTemplate(ctx context.Context, tmpl *domain.MessageTemplate) error {
+	if err := validateTemplateSource(tmpl); err != nil {
+		return err
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.MessageTemplate{}).
+		Where("event_type = ? AND language = ? AND channel = ?", tmpl.EventType, tmpl.Language, tmpl.Channel).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("error checking for existing template: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("template for event %s, language %s and channel %s already exists", tmpl.EventType, tmpl.Language, tmpl.Channel)
+	}
+
+	tmpl.TemplateID = 0
+	if err := r.db.WithContext(ctx).Create(tmpl).Error; err != nil {
+		return fmt.Errorf("could not create message template: %w", err)
+	}
+
+	return nil
+}
+
+func (r *messageTemplateRepository) UpdateTemplate(ctx context.Context, templateID int, tmpl *domain.MessageTemplate) error {
+	existing, err := r.GetTemplateByID(ctx, templateID)
+	if err != nil {
+		return err
+	}
+
+	// The key of a template cannot be changed, only its wording
+	existing.Subject = tmpl.Subject
+	existing.Body = tmpl.Body
+
+	if err := validateTemplateSource(existing); err != nil {
+		return err
+	}
+
+	err = r.db.WithContext(ctx).Model(&domain.MessageTemplate{}).
+		Where("template_id = ?", templateID).
+		Updates(map[string]interface{}{
+			"subject":    existing.Subject,
+			"body":       existing.Body,
+			"updated_at": time.Now(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("could not update message template: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTemplate removes a template, a template in the messenger language is kept since sends of its
+// event fall back to it and would fail until the next restart seeds it again. It can be reworded instead.
+func (r *messageTemplateRepository) DeleteTemplate(ctx context.Context, templateID int) error {
+	existing, err := r.GetTemplateByID(ctx, templateID)
+	if err != nil {
+		return err
+	}
+
+	if existing.Language == messengerLanguage() {
+		return fmt.Errorf("template %d is the %s template of event %s in the default language %s and cannot be deleted, update its wording instead",
+			templateID, existing.Channel, existing.EventType, existing.Language)
+	}
+
+	result := r.db.WithContext(ctx).Where("template_id = ?", templateID).Delete(&domain.MessageTemplate{})
+	if result.Error != nil {
+		return fmt.Errorf("could not delete message template: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("message template with ID %d not found", templateID)
+	}
+
+	return nil
+}
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"score": func(score *float64) string {
+		if score == nil {
+			return ""
+		}
+		return fmt.Sprintf("%.1f", *score)
+	},
+}
+
+// validateTemplateSource makes sure both parts of a template parse and render against sample data,
+// so a typo from the admin panel is rejected instead of breaking the next send.
+func validateTemplateSource(tmpl *domain.MessageTemplate) error {
+	sample := domain.TemplateData{
+		Scores:   []domain.SubjectAndScoreResult{{}},
+		Subjects: []domain.Subject{{}},
+	}
+
+	if _, err := renderTemplateString("subject", tmpl.Subject, sample); err != nil {
+		return fmt.Errorf("invalid subject template: %w", err)
+	}
+
+	if _, err := renderTemplateString("body", tmpl.Body, sample); err != nil {
+		return fmt.Errorf("invalid body template: %w", err)
+	}
+
+	return nil
+}
+
+func renderTemplateString(name, source string, data domain.TemplateData) (string, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// templateSet holds every template of one event type, keyed by language and channel.
+type templateSet map[string]domain.MessageTemplate
+
+func templateKey(language, channel string) string {
+	return language + "/" + channel
+}
+
+func loadTemplateSet(db *gorm.DB, eventType string) (templateSet, error) {
+	var templates []domain.MessageTemplate
+	if err := db.Where("event_type = ?", eventType).Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("could not load %s templates: %w", eventType, err)
+	}
+
+	set := make(templateSet, len(templates))
+	for _, tmpl := range templates {
+		set[templateKey(tmpl.Language, tmpl.Channel)] = tmpl
+	}
+	return set, nil
+}
+
+func (ts templateSet) has(language, channel string) bool {
+	_, ok := ts[templateKey(language, channel)]
+	return ok
+}
+
+func (ts templateSet) render(language, channel string, data domain.TemplateData) (*domain.Message, error) {
+	tmpl, ok := ts[templateKey(language, channel)]
+	if !ok {
+		return nil, fmt.Errorf("no template for language %s and channel %s", language, channel)
+	}
+
+	subject, err := renderTemplateString("subject", tmpl.Subject, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render subject of template %d: %w", tmpl.TemplateID, err)
+	}
+
+	body, err := renderTemplateString("body", tmpl.Body, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render body of template %d: %w", tmpl.TemplateID, err)
+	}
+
+	return &domain.Message{
+		Subject: strings.TrimSpace(subject),
+		Body:    body,
+	}, nil
+}
@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"notification/domain"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/skip2/go-qrcode"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+type whatsappSession struct {
+	client      *whatsmeow.Client
+	notifiers   domain.NotifierRegistry
+	adminEmail  *string
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	ctx         context.Context
+	log         *logrus.Logger
+
+	mu           sync.RWMutex
+	pairing      bool
+	reconnecting bool
+	replaced     bool
+	qrCode       string
+	qrExpiresAt  time.Time
+	lastEvent    *domain.WhatsAppEvent
+	subscribers  map[chan domain.WhatsAppEvent]struct{}
+}
+
+// NewWhatsAppSession manages the login of the school number at runtime, pairing happens through
+// the API instead of blocking the server start on a QR code. A dropped connection is retried after
+// backoffBase, doubled per failed attempt up to backoffMax, and adminEmail (when set) is told by
+// email once the session is logged out or taken over by another client.
+func NewWhatsAppSession(client *whatsmeow.Client, notifiers domain.NotifierRegistry, adminEmail *string, backoffBase, backoffMax time.Duration, log *logrus.Logger) domain.WhatsAppSessionRepo {
+	// The session reconnects on its own schedule, the client would otherwise race it
+	client.EnableAutoReconnect = false
+
+	s := &whatsappSession{
+		client:      client,
+		notifiers:   notifiers,
+		adminEmail:  adminEmail,
+		backoffBase: backoffBase,
+		backoffMax:  backoffMax,
+		ctx:         context.Background(),
+		log:         log,
+		subscribers: make(map[chan domain.WhatsAppEvent]struct{}),
+	}
+	client.AddEventHandler(s.handleEvent)
+	return s
+}
+
+// Start connects a paired device, or starts pairing when no device is linked yet. It does not wait for the pairing.
+// Reconnecting stops once ctx is cancelled.
+func (s *whatsappSession) Start(ctx context.Context) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.replaced = false
+	s.mu.Unlock()
+
+	if s.client.Store.ID == nil {
+		return s.startPairing()
+	}
+
+	if err := s.client.Connect(); err != nil {
+		// Keep trying in the background, the network may not be up yet
+		s.reconnect()
+		return fmt.Errorf("failed to connect to whatsapp: %w", err)
+	}
+	return nil
+}
+
+func (s *whatsappSession) GetStatus(ctx context.Context) (*domain.WhatsAppStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := domain.WhatsAppStatus{
+		Paired:      s.client.Store.ID != nil,
+		Connected:   s.client.IsConnected(),
+		LoggedIn:    s.client.IsLoggedIn(),
+		Pairing:     s.pairing,
+		QRAvailable: s.qrCode != "" && time.Now().Before(s.qrExpiresAt),
+	}
+
+	if s.client.Store.ID != nil {
+		jid := s.client.Store.ID.String()
+		status.JID = &jid
+	}
+
+	if s.lastEvent != nil {
+		status.LastEvent = s.lastEvent.Event
+		status.LastEventAt = &s.lastEvent.Time
+	}
+
+	return &status, nil
+}
+
+func (s *whatsappSession) GetQR(ctx context.Context) (*domain.WhatsAppQR, error) {
+	s.mu.RLock()
+	code, expiresAt := s.qrCode, s.qrExpiresAt
+	s.mu.RUnlock()
+
+	if code == "" || !time.Now().Before(expiresAt) {
+		return nil, fmt.Errorf("no QR code available, start pairing first")
+	}
+
+	png, err := qrcode.Encode(code, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	return &domain.WhatsAppQR{
+		Code:      code,
+		PNG:       base64.StdEncoding.EncodeToString(png),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Pair starts a new pairing, e.g. after a logout or once the previous QR codes ran out.
+func (s *whatsappSession) Pair(ctx context.Context) error {
+	if s.client.Store.ID != nil {
+		return fmt.Errorf("whatsapp is already paired as %s, log out first", s.client.Store.ID.String())
+	}
+
+	s.mu.Lock()
+	if s.pairing {
+		s.mu.Unlock()
+		return nil
+	}
+	s.pairing = true
+	s.mu.Unlock()
+
+	// A dropped connection may still be around, the QR channel must be requested before connecting
+	s.client.Disconnect()
+	if err := s.startPairing(); err != nil {
+		s.mu.Lock()
+		s.pairing = false
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Logout unlinks the school number, a new pairing is needed before WhatsApp messages can be sent again.
+func (s *whatsappSession) Logout(ctx context.Context) error {
+	if s.client.Store.ID == nil {
+		return fmt.Errorf("whatsapp is not paired")
+	}
+
+	if err := s.client.Logout(ctx); err != nil {
+		return fmt.Errorf("failed to log out of whatsapp: %w", err)
+	}
+
+	s.publish(domain.WhatsAppEventLoggedOut, "logged out through the API")
+	return nil
+}
+
+// Subscribe returns a channel of session events, the returned function must be called once the
+// subscriber is done. Events are dropped for subscribers that do not keep up.
+func (s *whatsappSession) Subscribe() (<-chan domain.WhatsAppEvent, func()) {
+	ch := make(chan domain.WhatsAppEvent, 16)
+
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers, ch)
+			s.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (s *whatsappSession) startPairing() error {
+	qrChan, err := s.client.GetQRChannel(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to start whatsapp pairing: %w", err)
+	}
+
+	if err := s.client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to whatsapp: %w", err)
+	}
+
+	s.mu.Lock()
+	s.pairing = true
+	s.mu.Unlock()
+
+	go func() {
+		for item := range qrChan {
+			switch item.Event {
+			case whatsmeow.QRChannelEventCode:
+				s.mu.Lock()
+				s.qrCode = item.Code
+				s.qrExpiresAt = time.Now().Add(item.Timeout)
+				s.mu.Unlock()
+				s.publish(domain.WhatsAppEventQR, "")
+			case whatsmeow.QRChannelSuccess.Event:
+				s.publish(domain.WhatsAppEventPairSuccess, "")
+			case whatsmeow.QRChannelTimeout.Event:
+				s.publish(domain.WhatsAppEventQRTimeout, "QR codes ran out, start pairing again")
+			default:
+				detail := item.Event
+				if item.Error != nil {
+					detail = item.Error.Error()
+				}
+				s.publish(domain.WhatsAppEventPairError, detail)
+			}
+		}
+
+		s.mu.Lock()
+		s.pairing = false
+		s.qrCode = ""
+		s.mu.Unlock()
+	}()
+
+	return nil
+}
+
+func (s *whatsappSession) handleEvent(rawEvt interface{}) {
+	switch evt := rawEvt.(type) {
+	case *events.Connected:
+		s.mu.Lock()
+		s.replaced = false
+		s.mu.Unlock()
+		s.publish(domain.WhatsAppEventConnected, "")
+	case *events.Disconnected:
+		s.publish(domain.WhatsAppEventDisconnect, "")
+		s.reconnect()
+	case *events.LoggedOut:
+		s.publish(domain.WhatsAppEventLoggedOut, evt.Reason.String())
+		go s.alertAdmin("WhatsApp session logged out", fmt.Sprintf(
+			"The school WhatsApp number was logged out (%s) at %s.\n\n"+
+				"WhatsApp notifications are held until the number is paired again, "+
+				"start a new pairing through POST /whatsapp/pair and scan the QR code.",
+			evt.Reason.String(), time.Now().Format("2006-01-02 15:04")))
+	case *events.StreamReplaced:
+		// Reconnecting would take the session back and the other client would do the same, over and over
+		s.mu.Lock()
+		s.replaced = true
+		s.mu.Unlock()
+		s.publish(domain.WhatsAppEventReplaced, "session opened elsewhere")
+		go s.alertAdmin("WhatsApp session replaced", fmt.Sprintf(
+			"Another client took over the session of the school WhatsApp number at %s, "+
+				"the server stopped reconnecting so the two do not keep replacing each other.\n\n"+
+				"WhatsApp notifications are held meanwhile. Close the other client and restart the server, "+
+				"or log out through POST /whatsapp/logout and pair again.",
+			time.Now().Format("2006-01-02 15:04")))
+	}
+}
+
+// reconnect keeps connecting a paired device with an exponential backoff until it is back, logged out or
+// the session is stopped. Only one loop runs at a time, the outbox holds WhatsApp messages meanwhile.
+// A session another client took over is left alone, taking it back would only start a tug of war.
+func (s *whatsappSession) reconnect() {
+	s.mu.Lock()
+	if s.reconnecting || s.pairing || s.replaced {
+		s.mu.Unlock()
+		return
+	}
+	s.reconnecting = true
+	ctx := s.ctx
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			s.reconnecting = false
+			s.mu.Unlock()
+		}()
+
+		delay := s.backoffBase
+		for attempt := 1; ; attempt++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			// A logout or a new pairing took over in the meantime
+			if s.client.Store.ID == nil || s.client.IsConnected() {
+				return
+			}
+
+			err := s.client.Connect()
+			if err == nil || errors.Is(err, whatsmeow.ErrAlreadyConnected) {
+				return
+			}
+
+			s.publish(domain.WhatsAppEventReconnect, fmt.Sprintf("attempt %d failed: %v", attempt, err))
+
+			delay *= 2
+			if delay > s.backoffMax {
+				delay = s.backoffMax
+			}
+		}
+	}()
+}
+
+// alertAdmin emails the admin that the school number cannot send anymore, e.g. after a logout,
+// WhatsApp messages stay in the outbox until someone fixes the session.
+func (s *whatsappSession) alertAdmin(subject, body string) {
+	if s.adminEmail == nil {
+		return
+	}
+
+	notifier, ok := s.notifiers.Get(domain.ChannelEmail)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	recipient := domain.Recipient{
+		Name:  "Admin",
+		Email: s.adminEmail,
+	}
+	message := domain.Message{
+		Subject: subject,
+		Body:    body,
+	}
+
+	if _, err := notifier.Send(ctx, recipient, message); err != nil {
+		s.log.Errorf("Failed to alert admin (%s): %v", subject, err)
+	}
+}
+
+func (s *whatsappSession) publish(event, detail string) {
+	evt := domain.WhatsAppEvent{
+		Event:  event,
+		Detail: detail,
+		Time:   time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastEvent = &evt
+	for ch := range s.subscribers {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
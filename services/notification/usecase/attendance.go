@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+)
+
+type attendanceUC struct {
+	repo       domain.AttendanceRepo
+	senderRepo domain.SenderRepo
+	TimeOut    time.Duration
+}
+
+func NewAttendanceUseCase(repo domain.AttendanceRepo, senderRepo domain.SenderRepo, timeOut time.Duration) domain.AttendanceUseCase {
+	return &attendanceUC{
+		repo:       repo,
+		senderRepo: senderRepo,
+		TimeOut:    timeOut,
+	}
+}
+
+// SubmitRollCall stores the register and notifies the parents of the absences it recorded in one go,
+// a register that cannot be notified is not stored either.
+func (a *attendanceUC) SubmitRollCall(ctx context.Context, rollCall *domain.RollCall, userID int) (*domain.RollCallResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.TimeOut)
+	defer cancel()
+
+	result, err := a.senderRepo.SendRollCall(ctx, rollCall, &userID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (a *attendanceUC) GetAttendances(ctx context.Context, date, subjectCode string) (*[]domain.Attendance, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.TimeOut)
+	defer cancel()
+
+	attendances, err := a.repo.GetAttendances(ctx, date, subjectCode)
+	if err != nil {
+		return nil, err
+	}
+	return attendances, nil
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+)
+
+type escalationRuleUC struct {
+	repo    domain.EscalationRuleRepo
+	TimeOut time.Duration
+}
+
+func NewEscalationRuleUseCase(repo domain.EscalationRuleRepo, timeOut time.Duration) domain.EscalationRuleUseCase {
+	return &escalationRuleUC{
+		repo:    repo,
+		TimeOut: timeOut,
+	}
+}
+
+func (e *escalationRuleUC) GetAllEscalationRules(ctx context.Context) (*[]domain.EscalationRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	rules, err := e.repo.GetAllEscalationRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (e *escalationRuleUC) CreateEscalationRule(ctx context.Context, rule *domain.EscalationRule) (*domain.EscalationRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	created, err := e.repo.CreateEscalationRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (e *escalationRuleUC) UpdateEscalationRule(ctx context.Context, escalationRuleID int, rule *domain.EscalationRule) error {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	err := e.repo.UpdateEscalationRule(ctx, escalationRuleID, rule)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *escalationRuleUC) DeleteEscalationRule(ctx context.Context, escalationRuleID int) error {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	err := e.repo.DeleteEscalationRule(ctx, escalationRuleID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *escalationRuleUC) GetEscalationAlerts(ctx context.Context, studentNSN string) (*[]domain.EscalationAlert, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	alerts, err := e.repo.GetEscalationAlerts(ctx, studentNSN)
+	if err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+)
+
+type excusedAbsenceUC struct {
+	repo    domain.ExcusedAbsenceRepo
+	TimeOut time.Duration
+}
+
+func NewExcusedAbsenceUseCase(repo domain.ExcusedAbsenceRepo, timeOut time.Duration) domain.ExcusedAbsenceUseCase {
+	return &excusedAbsenceUC{
+		repo:    repo,
+		TimeOut: timeOut,
+	}
+}
+
+func (e *excusedAbsenceUC) GetAllExcusedAbsences(ctx context.Context, studentNSN, status string) (*[]domain.ExcusedAbsence, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	excuses, err := e.repo.GetAllExcusedAbsences(ctx, studentNSN, status)
+	if err != nil {
+		return nil, err
+	}
+	return excuses, nil
+}
+
+func (e *excusedAbsenceUC) GetExcusedAbsenceByID(ctx context.Context, excusedAbsenceID int) (*domain.ExcusedAbsence, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	excuse, err := e.repo.GetExcusedAbsenceByID(ctx, excusedAbsenceID)
+	if err != nil {
+		return nil, err
+	}
+	return excuse, nil
+}
+
+func (e *excusedAbsenceUC) CreateExcusedAbsence(ctx context.Context, payload *domain.ExcusedAbsencePayload, userID int) (*domain.ExcusedAbsence, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	excuse, err := e.repo.CreateExcusedAbsence(ctx, payload, userID)
+	if err != nil {
+		return nil, err
+	}
+	return excuse, nil
+}
+
+func (e *excusedAbsenceUC) UpdateExcusedAbsence(ctx context.Context, excusedAbsenceID int, payload *domain.ExcusedAbsencePayload) error {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	err := e.repo.UpdateExcusedAbsence(ctx, excusedAbsenceID, payload)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *excusedAbsenceUC) DeleteExcusedAbsence(ctx context.Context, excusedAbsenceID int) error {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	err := e.repo.DeleteExcusedAbsence(ctx, excusedAbsenceID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *excusedAbsenceUC) ReviewExcusedAbsence(ctx context.Context, excusedAbsenceID int, payload *domain.ExcuseReviewPayload, userID int) (*domain.ExcusedAbsence, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.TimeOut)
+	defer cancel()
+
+	excuse, err := e.repo.ReviewExcusedAbsence(ctx, excusedAbsenceID, payload, userID)
+	if err != nil {
+		return nil, err
+	}
+	return excuse, nil
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+)
+
+type outboxUC struct {
+	repo    domain.OutboxRepo
+	TimeOut time.Duration
+}
+
+func NewOutboxUseCase(repo domain.OutboxRepo, timeOut time.Duration) domain.OutboxUseCase {
+	return &outboxUC{
+		repo:    repo,
+		TimeOut: timeOut,
+	}
+}
+
+func (o *outboxUC) DispatchNext(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.TimeOut)
+	defer cancel()
+
+	processed, err := o.repo.DispatchNext(ctx)
+	if err != nil {
+		return processed, err
+	}
+	return processed, nil
+}
+
+func (o *outboxUC) GetDeadMessages(ctx context.Context) (*[]domain.OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.TimeOut)
+	defer cancel()
+
+	msgs, err := o.repo.GetDeadMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (o *outboxUC) RedriveDeadMessage(ctx context.Context, outboxMessageID int) error {
+	ctx, cancel := context.WithTimeout(ctx, o.TimeOut)
+	defer cancel()
+
+	err := o.repo.RedriveDeadMessage(ctx, outboxMessageID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *outboxUC) RedriveDeadMessages(ctx context.Context, jobID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.TimeOut)
+	defer cancel()
+
+	count, err := o.repo.RedriveDeadMessages(ctx, jobID)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
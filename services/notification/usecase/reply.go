@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+)
+
+type parentReplyUC struct {
+	repo    domain.ParentReplyRepo
+	TimeOut time.Duration
+}
+
+func NewParentReplyUseCase(repo domain.ParentReplyRepo, timeOut time.Duration) domain.ParentReplyUseCase {
+	return &parentReplyUC{
+		repo:    repo,
+		TimeOut: timeOut,
+	}
+}
+
+func (p *parentReplyUC) GetAllParentReplies(ctx context.Context, unreadOnly bool) (*[]domain.ParentReply, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.TimeOut)
+	defer cancel()
+
+	replies, err := p.repo.GetAllParentReplies(ctx, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	return replies, nil
+}
+
+func (p *parentReplyUC) GetParentReplyMedia(ctx context.Context, parentReplyID int) (*domain.ParentReply, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.TimeOut)
+	defer cancel()
+
+	reply, err := p.repo.GetParentReplyMedia(ctx, parentReplyID)
+	if err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (p *parentReplyUC) MarkParentReplyRead(ctx context.Context, parentReplyID int, userID int) error {
+	ctx, cancel := context.WithTimeout(ctx, p.TimeOut)
+	defer cancel()
+
+	err := p.repo.MarkParentReplyRead(ctx, parentReplyID, userID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
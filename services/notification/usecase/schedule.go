@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+)
+
+type scheduledNotificationUC struct {
+	repo    domain.ScheduledNotificationRepo
+	TimeOut time.Duration
+}
+
+func NewScheduledNotificationUseCase(repo domain.ScheduledNotificationRepo, timeOut time.Duration) domain.ScheduledNotificationUseCase {
+	return &scheduledNotificationUC{
+		repo:    repo,
+		TimeOut: timeOut,
+	}
+}
+
+func (s *scheduledNotificationUC) GetAllScheduledNotifications(ctx context.Context, status string) (*[]domain.ScheduledNotification, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeOut)
+	defer cancel()
+
+	schedules, err := s.repo.GetAllScheduledNotifications(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (s *scheduledNotificationUC) CreateScheduledNotification(ctx context.Context, payload *domain.ScheduledNotificationPayload, userID int) (*domain.ScheduledNotification, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeOut)
+	defer cancel()
+
+	schedule, err := s.repo.CreateScheduledNotification(ctx, payload, userID)
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *scheduledNotificationUC) CancelScheduledNotification(ctx context.Context, scheduledNotificationID int) error {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeOut)
+	defer cancel()
+
+	err := s.repo.CancelScheduledNotification(ctx, scheduledNotificationID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *scheduledNotificationUC) RunNext(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeOut)
+	defer cancel()
+
+	processed, err := s.repo.RunNext(ctx)
+	if err != nil {
+		return processed, err
+	}
+	return processed, nil
+}
@@ -18,24 +18,79 @@ func NewSenderUseCase(repo domain.SenderRepo, timeOut time.Duration) domain.Send
 	}
 }
 
-func (mUC *senderUC) SendMass(ctx context.Context, nsnList *[]string, userID *int, subjectCode string) error {
-	// ctx, cancel := context.WithTimeout(ctx, mUC.TimeOut)
-	// defer cancel()
+func (mUC *senderUC) SendMass(ctx context.Context, nsnList *[]string, userID *int, subjectCode string, date *time.Time, idempotencyKey *string) (*domain.NotificationJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, mUC.TimeOut)
+	defer cancel()
 
-	err := mUC.emailSMTPRepo.SendMass(ctx, nsnList, userID, subjectCode)
+	job, err := mUC.emailSMTPRepo.SendMass(ctx, nsnList, userID, subjectCode, date, idempotencyKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return job, nil
 }
 
-func (mUC *senderUC) SendTestScores(ctx context.Context, examType string) error {
-	// ctx, cancel := context.WithTimeout(ctx, mUC.TimeOut)
-	// defer cancel()
+func (mUC *senderUC) SendRollCall(ctx context.Context, rollCall *domain.RollCall, userID *int) (*domain.RollCallResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, mUC.TimeOut)
+	defer cancel()
 
-	err := mUC.emailSMTPRepo.SendTestScores(ctx, examType)
+	result, err := mUC.emailSMTPRepo.SendRollCall(ctx, rollCall, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return result, nil
+}
+
+func (mUC *senderUC) SendDigest(ctx context.Context) (*domain.NotificationJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, mUC.TimeOut)
+	defer cancel()
+
+	job, err := mUC.emailSMTPRepo.SendDigest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (mUC *senderUC) SendTestScores(ctx context.Context, examType string, reportCard bool, userID *int, idempotencyKey *string) (*domain.NotificationJob, error) {
+	ctx, cancel := context.WithTimeout(ctx, mUC.TimeOut)
+	defer cancel()
+
+	job, err := mUC.emailSMTPRepo.SendTestScores(ctx, examType, reportCard, userID, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (mUC *senderUC) GetJobStatus(ctx context.Context, jobID string) (*domain.NotificationJobStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, mUC.TimeOut)
+	defer cancel()
+
+	status, err := mUC.emailSMTPRepo.GetJobStatus(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (mUC *senderUC) PreviewMass(ctx context.Context, nsnList *[]string, subjectCode string) (*domain.NotificationPreviewResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, mUC.TimeOut)
+	defer cancel()
+
+	preview, err := mUC.emailSMTPRepo.PreviewMass(ctx, nsnList, subjectCode)
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+func (mUC *senderUC) PreviewTestScores(ctx context.Context, examType string) (*domain.NotificationPreviewResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, mUC.TimeOut)
+	defer cancel()
+
+	preview, err := mUC.emailSMTPRepo.PreviewTestScores(ctx, examType)
+	if err != nil {
+		return nil, err
+	}
+	return preview, nil
 }
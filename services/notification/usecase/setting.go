@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+)
+
+type schoolSettingUC struct {
+	repo    domain.SchoolSettingRepo
+	TimeOut time.Duration
+}
+
+func NewSchoolSettingUseCase(repo domain.SchoolSettingRepo, timeOut time.Duration) domain.SchoolSettingUseCase {
+	return &schoolSettingUC{
+		repo:    repo,
+		TimeOut: timeOut,
+	}
+}
+
+func (s *schoolSettingUC) GetSchoolSetting(ctx context.Context) (*domain.SchoolSetting, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeOut)
+	defer cancel()
+
+	setting, err := s.repo.GetSchoolSetting(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+func (s *schoolSettingUC) UpdateSchoolSetting(ctx context.Context, payload *domain.SchoolSettingPayload) (*domain.SchoolSetting, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeOut)
+	defer cancel()
+
+	setting, err := s.repo.UpdateSchoolSetting(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+func (s *schoolSettingUC) GetAllSchoolHolidays(ctx context.Context) (*[]domain.SchoolHoliday, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeOut)
+	defer cancel()
+
+	holidays, err := s.repo.GetAllSchoolHolidays(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+func (s *schoolSettingUC) CreateSchoolHoliday(ctx context.Context, payload *domain.SchoolHolidayPayload) (*domain.SchoolHoliday, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeOut)
+	defer cancel()
+
+	holiday, err := s.repo.CreateSchoolHoliday(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return holiday, nil
+}
+
+func (s *schoolSettingUC) DeleteSchoolHoliday(ctx context.Context, schoolHolidayID int) error {
+	ctx, cancel := context.WithTimeout(ctx, s.TimeOut)
+	defer cancel()
+
+	err := s.repo.DeleteSchoolHoliday(ctx, schoolHolidayID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -155,3 +155,14 @@ func (spu *studentParentUseCase) ApproveDCR(ctx context.Context, req map[string]
 // 	}
 // 	return v, nil
 // }
+
+func (spu *studentParentUseCase) UpdateParentPreferences(ctx context.Context, parentID int, prefs *domain.ParentNotificationPreferences) (*domain.Parent, error) {
+	ctx, cancel := context.WithTimeout(ctx, spu.TimeOut)
+	defer cancel()
+
+	parent, err := spu.repo.UpdateParentPreferences(ctx, parentID, prefs)
+	if err != nil {
+		return nil, err
+	}
+	return parent, nil
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+)
+
+type messageTemplateUC struct {
+	repo    domain.MessageTemplateRepo
+	TimeOut time.Duration
+}
+
+func NewMessageTemplateUseCase(repo domain.MessageTemplateRepo, timeOut time.Duration) domain.MessageTemplateUseCase {
+	return &messageTemplateUC{
+		repo:    repo,
+		TimeOut: timeOut,
+	}
+}
+
+func (t *messageTemplateUC) GetAllTemplates(ctx context.Context) (*[]domain.MessageTemplate, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.TimeOut)
+	defer cancel()
+
+	templates, err := t.repo.GetAllTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (t *messageTemplateUC) GetTemplateByID(ctx context.Context, templateID int) (*domain.MessageTemplate, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.TimeOut)
+	defer cancel()
+
+	tmpl, err := t.repo.GetTemplateByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+func (t *messageTemplateUC) CreateTemplate(ctx context.Context, template *domain.MessageTemplate) error {
+	ctx, cancel := context.WithTimeout(ctx, t.TimeOut)
+	defer cancel()
+
+	err := t.repo.CreateTemplate(ctx, template)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *messageTemplateUC) UpdateTemplate(ctx context.Context, templateID int, template *domain.MessageTemplate) error {
+	ctx, cancel := context.WithTimeout(ctx, t.TimeOut)
+	defer cancel()
+
+	err := t.repo.UpdateTemplate(ctx, templateID, template)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *messageTemplateUC) DeleteTemplate(ctx context.Context, templateID int) error {
+	ctx, cancel := context.WithTimeout(ctx, t.TimeOut)
+	defer cancel()
+
+	err := t.repo.DeleteTemplate(ctx, templateID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"notification/domain"
+	"time"
+)
+
+type whatsappSessionUC struct {
+	repo    domain.WhatsAppSessionRepo
+	TimeOut time.Duration
+}
+
+func NewWhatsAppSessionUseCase(repo domain.WhatsAppSessionRepo, timeOut time.Duration) domain.WhatsAppSessionUseCase {
+	return &whatsappSessionUC{
+		repo:    repo,
+		TimeOut: timeOut,
+	}
+}
+
+func (w *whatsappSessionUC) GetStatus(ctx context.Context) (*domain.WhatsAppStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.TimeOut)
+	defer cancel()
+
+	status, err := w.repo.GetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (w *whatsappSessionUC) GetQR(ctx context.Context) (*domain.WhatsAppQR, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.TimeOut)
+	defer cancel()
+
+	qr, err := w.repo.GetQR(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return qr, nil
+}
+
+func (w *whatsappSessionUC) Pair(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, w.TimeOut)
+	defer cancel()
+
+	err := w.repo.Pair(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w *whatsappSessionUC) Logout(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, w.TimeOut)
+	defer cancel()
+
+	err := w.repo.Logout(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Subscribe is not bound by the timeout, the stream lasts as long as the subscriber listens.
+func (w *whatsappSessionUC) Subscribe() (<-chan domain.WhatsAppEvent, func()) {
+	return w.repo.Subscribe()
+}